@@ -0,0 +1,254 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/e-aleixandre/go-blockchain/script"
+	"log"
+	"slices"
+)
+
+const (
+	addrIndexPrefix = "addr-"
+	addrIndexKey    = "addrindex"
+)
+
+type AddressTransaction struct {
+	TxID           []byte
+	BlockHash      []byte
+	Height         int
+	Position       int
+	Timestamp      int64
+	Coinbase       bool
+	Received       int
+	Sent           int
+	Counterparties []string
+}
+
+func (entry *AddressTransaction) Serialize() []byte {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(entry); err != nil {
+		log.Panic(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func DeserializeAddressTransaction(data []byte) (AddressTransaction, error) {
+	var entry AddressTransaction
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry)
+
+	return entry, err
+}
+
+func addressPrefix(lockingScript []byte) []byte {
+	return append([]byte(addrIndexPrefix), script.Hash160(lockingScript)...)
+}
+
+func addressEntryKey(lockingScript []byte, height, position int) []byte {
+	key := binary.BigEndian.AppendUint32(addressPrefix(lockingScript), uint32(height))
+
+	return binary.BigEndian.AppendUint32(key, uint32(position))
+}
+
+func (chain *Blockchain) GetAddressTransactions(lockingScript []byte) ([]AddressTransaction, error) {
+	var entries []AddressTransaction
+
+	prefix := addressPrefix(lockingScript)
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				entry, err := DeserializeAddressTransaction(val)
+
+				if err != nil {
+					return err
+				}
+
+				entries = append(entries, entry)
+
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return entries, err
+}
+
+func addressEntries(params *Params, block *Block, undo []spentOutput) map[string]*AddressTransaction {
+	entries := make(map[string]*AddressTransaction)
+
+	for position, tx := range block.Transactions {
+		var spent []TxOutput
+
+		if !tx.IsCoinbase() {
+			for range tx.Inputs {
+				if len(undo) == 0 {
+					break
+				}
+
+				spent = append(spent, undo[0].Output.TxOutput)
+				undo = undo[1:]
+			}
+		}
+
+		var scripts [][]byte
+
+		touch := func(lockingScript []byte) *AddressTransaction {
+			key := string(addressEntryKey(lockingScript, block.Height, position))
+
+			if entries[key] == nil {
+				entries[key] = &AddressTransaction{
+					TxID:      tx.ID,
+					BlockHash: block.Hash,
+					Height:    block.Height,
+					Position:  position,
+					Timestamp: block.Timestamp,
+					Coinbase:  tx.IsCoinbase(),
+				}
+				scripts = append(scripts, lockingScript)
+			}
+
+			return entries[key]
+		}
+
+		for _, out := range spent {
+			touch(out.Script).Sent += out.Value
+		}
+
+		for _, out := range tx.Outputs {
+			if !out.IsUnspendable() {
+				touch(out.Script).Received += out.Value
+			}
+		}
+
+		for _, lockingScript := range scripts {
+			entry := entries[string(addressEntryKey(lockingScript, block.Height, position))]
+			others := spent
+
+			if entry.Sent > 0 {
+				others = tx.Outputs
+			}
+
+			for _, out := range others {
+				address, ok := ExtractAddress(params, out.Script)
+
+				if ok && !out.IsLockedWith(lockingScript) && !slices.Contains(entry.Counterparties, address) {
+					entry.Counterparties = append(entry.Counterparties, address)
+				}
+			}
+		}
+	}
+
+	return entries
+}
+
+func indexAddresses(txn *badger.Txn, params *Params, block *Block, undo []spentOutput) error {
+	for key, entry := range addressEntries(params, block, undo) {
+		if err := txn.Set([]byte(key), entry.Serialize()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unindexAddresses(txn *badger.Txn, params *Params, block *Block, undo []spentOutput) error {
+	for key := range addressEntries(params, block, undo) {
+		if err := txn.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (chain *Blockchain) ReindexAddresses() error {
+	if err := chain.deleteByPrefix([]byte(addrIndexPrefix)); err != nil {
+		return err
+	}
+
+	best, err := chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	outputs := make(map[string]UTXO)
+	batch := chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	for height := 0; height <= best; height++ {
+		block, err := chain.GetBlockByHeight(height)
+
+		if err != nil {
+			return err
+		}
+
+		var undo []spentOutput
+
+		for _, tx := range block.Transactions {
+			if !tx.IsCoinbase() {
+				for _, in := range tx.Inputs {
+					key := string(utxoKey(in.ID, in.Out))
+					out, ok := outputs[key]
+
+					if !ok {
+						return fmt.Errorf("transaction %x spends unknown output %x:%d", tx.ID, in.ID, in.Out)
+					}
+
+					undo = append(undo, spentOutput{in.ID, in.Out, out})
+					delete(outputs, key)
+				}
+			}
+
+			for outIdx, out := range tx.Outputs {
+				outputs[string(utxoKey(tx.ID, outIdx))] = UTXO{TxOutput: out}
+			}
+		}
+
+		for key, entry := range addressEntries(chain.Params, block, undo) {
+			if err := batch.Set([]byte(key), entry.Serialize()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := batch.Set([]byte(addrIndexKey), []byte{1}); err != nil {
+		return err
+	}
+
+	return batch.Flush()
+}
+
+func (chain *Blockchain) checkAddressIndex() error {
+	var indexed bool
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		var err error
+
+		indexed, err = hasKey(txn, addrIndexKey)
+
+		return err
+	})
+
+	if err != nil || indexed {
+		return err
+	}
+
+	return chain.ReindexAddresses()
+}
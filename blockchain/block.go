@@ -2,44 +2,94 @@ package blockchain
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"context"
 	"encoding/gob"
+	"errors"
+	"github.com/e-aleixandre/go-blockchain/merkle"
 	"log"
 )
 
+const BlockVersion = 1
+
+type BlockHeader struct {
+	Version    int
+	Height     int
+	Timestamp  int64
+	PrevHash   []byte
+	MerkleRoot []byte
+	Bits       int
+	Nonce      int
+}
+
 type Block struct {
+	BlockHeader
 	Hash         []byte
 	Transactions []*Transaction
-	PrevHash     []byte
-	Nonce        int
 }
 
-func Genesis(coinbase *Transaction) *Block {
-	return CreateBlock([]*Transaction{coinbase}, []byte{})
+func Genesis(ctx context.Context, miner *Miner, coinbase *Transaction, bits int, timestamp int64) (*Block, error) {
+	return CreateBlock(ctx, miner, []*Transaction{coinbase}, BlockHeader{
+		Timestamp: timestamp,
+		PrevHash:  []byte{},
+		Bits:      bits,
+	})
 }
 
-func CreateBlock(txs []*Transaction, prevHash []byte) *Block {
-	block := &Block{[]byte{}, txs, prevHash, 0}
-	pow := NewProof(block)
-	nonce, hash := pow.Run()
+func CreateBlock(ctx context.Context, miner *Miner, txs []*Transaction, header BlockHeader) (*Block, error) {
+	block := &Block{BlockHeader: header, Transactions: txs}
+	block.Version = BlockVersion
+	block.MerkleRoot = block.HashTransactions()
+
+	pow := NewProof(block, block.Bits)
+	nonce, hash, err := miner.Mine(ctx, pow)
 
-	block.Hash = hash[:]
+	if err != nil {
+		return nil, err
+	}
+
+	block.Hash = hash
 	block.Nonce = nonce
 
-	return block
+	return block, nil
+}
 
+func (header *BlockHeader) Serialize() []byte {
+	return bytes.Join(
+		[][]byte{
+			toHex(int64(header.Version)),
+			toHex(int64(header.Height)),
+			toHex(header.Timestamp),
+			header.PrevHash,
+			header.MerkleRoot,
+			toHex(int64(header.Bits)),
+			toHex(int64(header.Nonce)),
+		},
+		[]byte{},
+	)
 }
 
-func (block *Block) HashTransactions() []byte {
+func (block *Block) merkleTree() *merkle.Tree {
 	var txHashes [][]byte
-	var txHash [32]byte
 
 	for _, tx := range block.Transactions {
 		txHashes = append(txHashes, tx.ID)
 	}
-	txHash = sha256.Sum256(bytes.Join(txHashes, []byte{}))
 
-	return txHash[:]
+	return merkle.NewTree(txHashes)
+}
+
+func (block *Block) HashTransactions() []byte {
+	return block.merkleTree().Root()
+}
+
+func (block *Block) MerkleProof(txID []byte) (*merkle.Proof, error) {
+	for i, tx := range block.Transactions {
+		if bytes.Equal(tx.ID, txID) {
+			return block.merkleTree().Proof(i)
+		}
+	}
+
+	return nil, errors.New("transaction is not in the block")
 }
 
 func (block *Block) Serialize() []byte {
@@ -53,14 +103,14 @@ func (block *Block) Serialize() []byte {
 	return res.Bytes()
 }
 
-func Deserialize(data []byte) *Block {
+func Deserialize(data []byte) (*Block, error) {
 	var block Block
 
 	decoder := gob.NewDecoder(bytes.NewReader(data))
 
 	if err := decoder.Decode(&block); err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	return &block
+	return &block, nil
 }
@@ -2,240 +2,458 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/dgraph-io/badger/v4"
-	"log"
 	"os"
-	"runtime"
+	"path/filepath"
+	"sync"
+	"time"
 )
 
 const (
-	dbPath      = "./tmp/blocks"
-	dbFile      = "./tmp/blocks/MANIFEST"
-	genesisData = "This is where it all started"
+	dbDir      = "blocks"
+	networkKey = "network"
 )
 
 type Blockchain struct {
-	LastHash []byte
 	Database *badger.DB
+	Params   *Params
+	Miner    *Miner
+	Clock    func() time.Time
+	TxIndex  bool
+
+	tipMu    sync.RWMutex
+	lastHash []byte
 }
 
-func InitBlockchain(address string) *Blockchain {
-	var lastHash []byte
+func openDB(dir string) (*badger.DB, error) {
+	opts := badger.DefaultOptions(filepath.Join(dir, dbDir))
+	opts.Logger = nil
 
-	if DBExists() {
-		fmt.Println("Blockchain already exists!")
-		runtime.Goexit()
+	return badger.Open(opts)
+}
+
+func InitBlockchain(dir string, params *Params, address string, clock func() time.Time) (*Blockchain, error) {
+	if DBExists(dir) {
+		return nil, ErrChainExists
 	}
 
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil
-	db, err := badger.Open(opts)
+	chain := &Blockchain{Params: params, Miner: NewMiner(0), Clock: clock}
+
+	cbtx, err := CoinbaseTx(params, address, params.GenesisData, 0, 0)
 
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	err = db.Update(func(txn *badger.Txn) error {
-		cbtx := CoinbaseTx(address, genesisData)
-		genesis := Genesis(cbtx)
-		fmt.Println("Genesis created")
+	genesis, err := Genesis(context.Background(), chain.Miner, cbtx, params.InitialBits, chain.now().Unix())
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Genesis created")
+
+	db, err := openDB(dir)
+
+	if err != nil {
+		return nil, err
+	}
 
+	err = db.Update(func(txn *badger.Txn) error {
 		err := txn.Set(genesis.Hash, genesis.Serialize())
 
 		if err != nil {
-			log.Panic(err)
+			return err
+		}
+
+		err = txn.Set(append([]byte(workPrefix), genesis.Hash...), blockWork(genesis.Bits).Bytes())
+
+		if err != nil {
+			return err
+		}
+
+		err = txn.Set(heightKey(genesis.Height), genesis.Hash)
+
+		if err != nil {
+			return err
+		}
+
+		err = indexAddresses(txn, params, genesis, nil)
+
+		if err != nil {
+			return err
+		}
+
+		err = txn.Set([]byte(addrIndexKey), []byte{1})
+
+		if err != nil {
+			return err
 		}
 
 		err = txn.Set([]byte("lh"), genesis.Hash)
-		lastHash = genesis.Hash
 
-		return err
+		if err != nil {
+			return err
+		}
+
+		err = txn.Set([]byte(networkKey), []byte(params.Name))
+
+		if err != nil {
+			return err
+		}
+
+		return updateUTXO(txn, genesis)
 	})
 
 	if err != nil {
-		log.Panic(err)
+		db.Close()
+
+		return nil, err
 	}
 
-	return &Blockchain{lastHash, db}
+	chain.lastHash = genesis.Hash
+	chain.Database = db
+
+	return chain, nil
 }
 
-func ContinueBlockchain(address string) *Blockchain {
-	if !DBExists() {
-		fmt.Println("No existing blockchain found")
-		runtime.Goexit()
+func ContinueBlockchain(dir string, params *Params) (*Blockchain, error) {
+	if !DBExists(dir) {
+		return nil, ErrChainNotFound
 	}
 
 	var lastHash []byte
+	var txIndex bool
 
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil
-	db, err := badger.Open(opts)
+	db, err := openDB(dir)
 
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	err = db.View(func(txn *badger.Txn) error {
+	err = db.Update(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte("lh"))
 
 		if err != nil {
 			return err
 		}
 
-		err = item.Value(func(val []byte) error {
-			lastHash = val
+		lastHash, err = item.ValueCopy(nil)
 
-			return nil
-		})
+		if err != nil {
+			return err
+		}
 
-		return err
+		if txIndex, err = hasKey(txn, txIndexKey); err != nil {
+			return err
+		}
+
+		return checkNetwork(txn, params)
 	})
 
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		err = ErrChainNotFound
+	}
+
+	if err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	chain := &Blockchain{Database: db, Params: params, Miner: NewMiner(0), TxIndex: txIndex, lastHash: lastHash}
+
+	if err := chain.validateTip(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	if err := chain.checkHeightIndex(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	if err := chain.checkUTXOSet(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	if err := chain.checkAddressIndex(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+func (chain *Blockchain) Tip() []byte {
+	chain.tipMu.RLock()
+	defer chain.tipMu.RUnlock()
+
+	return chain.lastHash
+}
+
+func (chain *Blockchain) setTip(hash []byte) {
+	chain.tipMu.Lock()
+	defer chain.tipMu.Unlock()
+
+	chain.lastHash = hash
+}
+
+func (chain *Blockchain) validateTip() error {
+	tip, err := chain.GetBlock(chain.Tip())
+
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
 
-	return &Blockchain{lastHash, db}
+	return chain.validateHeader(tip)
 }
 
-func DBExists() bool {
-	if _, err := os.Stat(dbFile); os.IsNotExist(err) {
+func checkNetwork(txn *badger.Txn, params *Params) error {
+	item, err := txn.Get([]byte(networkKey))
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return txn.Set([]byte(networkKey), []byte(params.Name))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return item.Value(func(val []byte) error {
+		if string(val) != params.Name {
+			return fmt.Errorf("%w: %s", ErrWrongNetwork, val)
+		}
+
+		return nil
+	})
+}
+
+func DBExists(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, dbDir, "MANIFEST")); os.IsNotExist(err) {
 		return false
 	}
 
 	return true
 }
 
-func (chain *Blockchain) AddBlock(transactions []*Transaction) {
-	var lastHash []byte
+func (chain *Blockchain) AddBlock(transactions []*Transaction) error {
+	_, err := chain.MineBlock(context.Background(), transactions)
 
-	err := chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte("lh"))
+	return err
+}
 
-		if err != nil {
-			log.Panic(err)
-		}
+func (chain *Blockchain) MineBlock(ctx context.Context, transactions []*Transaction) (*Block, error) {
+	lastBlock, err := chain.GetBlock(chain.Tip())
+
+	if err != nil {
+		return nil, err
+	}
 
-		err = item.Value(func(val []byte) error {
-			lastHash = append([]byte{}, val...)
+	bits, err := chain.NextBits(lastBlock)
 
-			return nil
-		})
+	if err != nil {
+		return nil, err
+	}
 
-		return err
+	timestamp, err := chain.nextTimestamp(lastBlock)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newBlock, err := CreateBlock(ctx, chain.Miner, transactions, BlockHeader{
+		Height:    lastBlock.Height + 1,
+		Timestamp: timestamp,
+		PrevHash:  lastBlock.Hash,
+		Bits:      bits,
 	})
 
 	if err != nil {
-		log.Panic(err)
+		return nil, err
+	}
+
+	if _, err := chain.ImportBlock(newBlock); err != nil {
+		return nil, err
 	}
 
-	newBlock := CreateBlock(transactions, lastHash)
+	return newBlock, nil
+}
+
+func (chain *Blockchain) GetBlock(hash []byte) (*Block, error) {
+	var block *Block
 
-	err = chain.Database.Update(func(txn *badger.Txn) error {
-		err := txn.Set(newBlock.Hash, newBlock.Serialize())
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(hash)
 
 		if err != nil {
 			return err
 		}
 
-		err = txn.Set([]byte("lh"), newBlock.Hash)
-		chain.LastHash = newBlock.Hash
+		return item.Value(func(val []byte) error {
+			block, err = Deserialize(val)
 
-		return err
+			return err
+		})
 	})
 
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrBlockNotFound
+	}
+
 	if err != nil {
-		log.Panic(err)
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func (chain *Blockchain) HasBlock(hash []byte) (bool, error) {
+	_, err := chain.GetBlock(hash)
+
+	if errors.Is(err, ErrBlockNotFound) {
+		return false, nil
 	}
+
+	return err == nil, err
 }
 
-func (chain *Blockchain) FindUnspentTransactions(publicKeyHash []byte) []Transaction {
-	var unspentTxs []Transaction
+func (chain *Blockchain) GetBestHeight() (int, error) {
+	block, err := chain.GetBlock(chain.Tip())
 
-	spentTxOutputs := make(map[string][]int)
+	if err != nil {
+		return 0, err
+	}
+
+	return block.Height, nil
+}
+
+func (chain *Blockchain) mainChainHashes() ([][]byte, error) {
+	var hashes [][]byte
 
 	iter := chain.Iterator()
 
 	for {
-		block := iter.Next()
-
-		for _, tx := range block.Transactions {
-			txID := hex.EncodeToString(tx.ID)
-
-		Outputs:
-			for outIdx, out := range tx.Outputs {
-				if spentTxOutputs[txID] != nil {
-					for _, spentOut := range spentTxOutputs[txID] {
-						if spentOut == outIdx {
-							continue Outputs
-						}
-
-					}
-				}
-
-				if out.IsLockedWithKey(publicKeyHash) {
-					unspentTxs = append(unspentTxs, *tx)
-				}
-			}
-
-			if !tx.IsCoinbase() {
-				for _, in := range tx.Inputs {
-					if in.UsesKey(publicKeyHash) {
-						inTxID := hex.EncodeToString(in.ID)
-						spentTxOutputs[inTxID] = append(spentTxOutputs[inTxID], in.Out)
-					}
-				}
-			}
+		block, err := iter.Next()
+
+		if err != nil {
+			return nil, err
 		}
 
+		hashes = append(hashes, block.Hash)
+
 		if len(block.PrevHash) == 0 {
 			break
 		}
 	}
 
-	return unspentTxs
+	return hashes, nil
 }
 
-func (chain *Blockchain) FindUTXO(pubKeyHash []byte) []TxOutput {
-	var UTXOs []TxOutput
+func (chain *Blockchain) GetBlockLocator() ([][]byte, error) {
+	var locator [][]byte
+
+	height, err := chain.GetBestHeight()
 
-	transactions := chain.FindUnspentTransactions(pubKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	step := 1
+
+	for ; height > 0; height -= step {
+		hash, err := chain.GetBlockHash(height)
 
-	for _, tx := range transactions {
-		for _, out := range tx.Outputs {
-			if out.IsLockedWithKey(pubKeyHash) {
-				UTXOs = append(UTXOs, out)
-			}
+		if err != nil {
+			return nil, err
+		}
+
+		locator = append(locator, hash)
+
+		if len(locator) >= 10 {
+			step *= 2
 		}
 	}
 
-	return UTXOs
+	genesis, err := chain.GetBlockHash(0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(locator, genesis), nil
 }
 
-func (chain *Blockchain) FindSpendableOutputs(pubKeyHash []byte, amount int) (int, map[string][]int) {
-	unspentOuts := make(map[string][]int)
-	unspentTxs := chain.FindUnspentTransactions(pubKeyHash)
-	accumulated := 0
+func (chain *Blockchain) mainChainHeight(hash []byte) (int, bool, error) {
+	block, err := chain.GetBlock(hash)
 
-Work:
-	for _, tx := range unspentTxs {
-		txID := hex.EncodeToString(tx.ID)
+	if errors.Is(err, ErrBlockNotFound) {
+		return 0, false, nil
+	}
 
-		for outIdx, out := range tx.Outputs {
-			accumulated += out.Value
-			unspentOuts[txID] = append(unspentOuts[txID], outIdx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	mainHash, err := chain.GetBlockHash(block.Height)
+
+	if errors.Is(err, ErrBlockNotFound) {
+		return 0, false, nil
+	}
 
-			if accumulated >= amount {
-				break Work
-			}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return block.Height, bytes.Equal(mainHash, hash), nil
+}
+
+func (chain *Blockchain) GetBlockHashesAfter(locator [][]byte, limit int) ([][]byte, error) {
+	var after [][]byte
+
+	start := 0
+
+	for _, hash := range locator {
+		height, ok, err := chain.mainChainHeight(hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			start = height + 1
+
+			break
 		}
 	}
 
-	return accumulated, unspentOuts
+	bestHeight, err := chain.GetBestHeight()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for height := start; height <= bestHeight && len(after) < limit; height++ {
+		hash, err := chain.GetBlockHash(height)
+
+		if err != nil {
+			return nil, err
+		}
+
+		after = append(after, hash)
+	}
+
+	return after, nil
 }
 
 type Iterator struct {
@@ -244,12 +462,12 @@ type Iterator struct {
 }
 
 func (chain *Blockchain) Iterator() *Iterator {
-	iter := &Iterator{chain.LastHash, chain.Database}
+	iter := &Iterator{chain.Tip(), chain.Database}
 
 	return iter
 }
 
-func (iterator *Iterator) Next() *Block {
+func (iterator *Iterator) Next() (*Block, error) {
 	var block *Block
 
 	err := iterator.Database.View(func(txn *badger.Txn) error {
@@ -259,79 +477,86 @@ func (iterator *Iterator) Next() *Block {
 			return err
 		}
 
-		err = item.Value(func(val []byte) error {
-			block = Deserialize(val)
+		return item.Value(func(val []byte) error {
+			block, err = Deserialize(val)
 
-			return nil
+			return err
 		})
-
-		return err
 	})
 
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrBlockNotFound
+	}
+
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
 	iterator.CurrentHash = block.PrevHash
 
-	return block
+	return block, nil
 }
 
-func (chain *Blockchain) ShutdownDB() {
-	err := chain.Database.Close()
-
-	if err != nil {
-		log.Panic(err)
-	}
+func (chain *Blockchain) ShutdownDB() error {
+	return chain.Database.Close()
 }
-func (chain *Blockchain) FindTransaction(ID []byte) (Transaction, error) {
-	iter := chain.Iterator()
 
-	for {
-		block := iter.Next()
-
-		for _, tx := range block.Transactions {
-			if bytes.Compare(tx.ID, ID) == 0 {
-				return *tx, nil
-			}
-		}
+func (chain *Blockchain) FindTransaction(ID []byte) (Transaction, error) {
+	block, position, err := chain.LocateTransaction(ID)
 
-		if len(block.PrevHash) == 0 {
-			break
-		}
+	if err != nil {
+		return Transaction{}, err
 	}
 
-	return Transaction{}, errors.New("transaction does not exist")
+	return *block.Transactions[position], nil
 }
 
-func (chain *Blockchain) SignTransaction(tx *Transaction, privKey ecdsa.PrivateKey) {
+func (chain *Blockchain) findPrevTransactions(tx *Transaction) (map[string]Transaction, error) {
 	prevTxs := make(map[string]Transaction)
 
 	for _, in := range tx.Inputs {
 		prevTx, err := chain.FindTransaction(in.ID)
 
 		if err != nil {
-			log.Panic(err)
+			return nil, err
 		}
 
 		prevTxs[hex.EncodeToString(in.ID)] = prevTx
 	}
 
-	tx.Sign(privKey, prevTxs)
+	return prevTxs, nil
 }
 
-func (chain *Blockchain) VerifyTransaction(tx *Transaction) bool {
-	prevTxs := make(map[string]Transaction)
+func (chain *Blockchain) SignTransaction(tx *Transaction, privKey ecdsa.PrivateKey) error {
+	prevTxs, err := chain.findPrevTransactions(tx)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Sign(privKey, prevTxs)
+}
+
+func (chain *Blockchain) VerifyTransaction(tx *Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
+
+	var prevOuts []TxOutput
 
 	for _, in := range tx.Inputs {
-		prevTx, err := chain.FindTransaction(in.ID)
+		out, ok, err := chain.GetUTXO(in.ID, in.Out)
 
 		if err != nil {
-			log.Panic(err)
+			return err
 		}
 
-		prevTxs[hex.EncodeToString(in.ID)] = prevTx
+		if !ok {
+			return fmt.Errorf("transaction %x spends missing or spent output %x:%d", tx.ID, in.ID, in.Out)
+		}
+
+		prevOuts = append(prevOuts, out.TxOutput)
 	}
 
-	return tx.Verify(prevTxs)
+	return tx.Verify(prevOuts)
 }
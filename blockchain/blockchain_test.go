@@ -0,0 +1,167 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"github.com/e-aleixandre/go-blockchain/script"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	privKey       ecdsa.PrivateKey
+	address       string
+	lockingScript []byte
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+
+	w, err := wallet.MakeWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubKeyHash := wallet.PublicKeyHash(w.PublicKey)
+
+	return &testKey{
+		privKey:       *w.PrivateKey,
+		address:       string(wallet.EncodeAddress(pubKeyHash, RegTestParams.AddressVersion)),
+		lockingScript: script.PayToPubKeyHash(pubKeyHash),
+	}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (clock *testClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *testClock) Advance(d time.Duration) {
+	clock.now = clock.now.Add(d)
+}
+
+func newTestChain(t *testing.T, miner *testKey) *Blockchain {
+	t.Helper()
+
+	return newClockedTestChain(t, miner, nil)
+}
+
+func newClockedTestChain(t *testing.T, miner *testKey, clock func() time.Time) *Blockchain {
+	t.Helper()
+
+	chain, err := InitBlockchain(t.TempDir(), &RegTestParams, miner.address, clock)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { chain.ShutdownDB() })
+
+	return chain
+}
+
+func buildBlock(t *testing.T, chain *Blockchain, prev *Block, miner *testKey, txs ...*Transaction) *Block {
+	t.Helper()
+
+	return buildBlockAt(t, chain, prev, miner, prev.Timestamp+1, txs...)
+}
+
+func buildBlockAt(t *testing.T, chain *Blockchain, prev *Block, miner *testKey, timestamp int64, txs ...*Transaction) *Block {
+	t.Helper()
+
+	coinbase, err := CoinbaseTx(chain.Params, miner.address, "", prev.Height+1, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bits, err := chain.NextBits(prev)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := CreateBlock(context.Background(), chain.Miner, append([]*Transaction{coinbase}, txs...), BlockHeader{
+		Height:    prev.Height + 1,
+		Timestamp: timestamp,
+		PrevHash:  prev.Hash,
+		Bits:      bits,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return block
+}
+
+func importBlock(t *testing.T, chain *Blockchain, block *Block) *ChainUpdate {
+	t.Helper()
+
+	update, err := chain.ImportBlock(block)
+
+	if err != nil {
+		t.Fatalf("importing block at height %d: %v", block.Height, err)
+	}
+
+	return update
+}
+
+func mineBlocks(t *testing.T, chain *Blockchain, miner *testKey, count int) []*Block {
+	t.Helper()
+
+	var blocks []*Block
+
+	for i := 0; i < count; i++ {
+		tip, err := chain.GetBlock(chain.Tip())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		block := buildBlock(t, chain, tip, miner)
+		importBlock(t, chain, block)
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+func spend(t *testing.T, from *testKey, prevTx *Transaction, outIdx int, to *testKey, amount, fee int) *Transaction {
+	t.Helper()
+
+	prevOut := prevTx.Outputs[outIdx]
+	outputs := []TxOutput{{amount, to.lockingScript}}
+
+	if change := prevOut.Value - amount - fee; change > 0 {
+		outputs = append(outputs, TxOutput{change, from.lockingScript})
+	}
+
+	tx := &Transaction{nil, []TxInput{{prevTx.ID, outIdx, nil, SequenceFinal}}, outputs, 0}
+
+	if err := tx.Sign(from.privKey, map[string]Transaction{hex.EncodeToString(prevTx.ID): *prevTx}); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.ID = tx.Hash()
+
+	return tx
+}
+
+func balance(t *testing.T, chain *Blockchain, key *testKey) int {
+	t.Helper()
+
+	spendable, immature, err := chain.GetBalance(key.lockingScript)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return spendable + immature
+}
@@ -0,0 +1,110 @@
+package blockchain
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	maxRetargetFactor = 4
+	medianTimeSpan    = 11
+)
+
+func (chain *Blockchain) now() time.Time {
+	if chain.Clock != nil {
+		return chain.Clock()
+	}
+
+	return time.Now()
+}
+
+func (chain *Blockchain) MedianTimePast(prev *Block) (int64, error) {
+	var timestamps []int64
+
+	block := prev
+
+	for {
+		timestamps = append(timestamps, block.Timestamp)
+
+		if len(timestamps) == medianTimeSpan || len(block.PrevHash) == 0 {
+			break
+		}
+
+		var err error
+
+		if block, err = chain.GetBlock(block.PrevHash); err != nil {
+			return 0, err
+		}
+	}
+
+	slices.Sort(timestamps)
+
+	return timestamps[len(timestamps)/2], nil
+}
+
+func (chain *Blockchain) nextTimestamp(prev *Block) (int64, error) {
+	median, err := chain.MedianTimePast(prev)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return max(chain.now().Unix(), median+1), nil
+}
+
+func (chain *Blockchain) ExpectedBits(block *Block) (int, error) {
+	if len(block.PrevHash) == 0 {
+		return chain.Params.InitialBits, nil
+	}
+
+	prev, err := chain.GetBlock(block.PrevHash)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return chain.NextBits(prev)
+}
+
+func (chain *Blockchain) NextBits(prev *Block) (int, error) {
+	interval := chain.Params.RetargetInterval
+
+	if interval < 2 || (prev.Height+1)%interval != 0 {
+		return prev.Bits, nil
+	}
+
+	first := prev
+
+	for i := 0; i < interval-1; i++ {
+		block, err := chain.GetBlock(first.PrevHash)
+
+		if err != nil {
+			return 0, err
+		}
+
+		first = block
+	}
+
+	expected := chain.Params.TargetSpacing.Seconds() * float64(interval-1)
+	actual := float64(prev.Timestamp - first.Timestamp)
+
+	return retarget(prev.Bits, expected, actual, chain.Params), nil
+}
+
+func retarget(bits int, expected, actual float64, params *Params) int {
+	actual = math.Max(actual, expected/maxRetargetFactor)
+	actual = math.Min(actual, expected*maxRetargetFactor)
+
+	bits += int(math.Round(math.Log2(expected / actual)))
+
+	if bits < params.MinBits {
+		return params.MinBits
+	}
+
+	if bits > params.MaxBits {
+		return params.MaxBits
+	}
+
+	return bits
+}
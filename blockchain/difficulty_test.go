@@ -0,0 +1,125 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mineNow(t *testing.T, chain *Blockchain, miner *testKey) *Block {
+	t.Helper()
+
+	height, err := chain.GetBestHeight()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbase, err := CoinbaseTx(chain.Params, miner.address, "", height+1, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := chain.MineBlock(context.Background(), []*Transaction{coinbase})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return block
+}
+
+func TestDifficultyConvergesToTargetSpacing(t *testing.T) {
+	const hashRate = 1 << 5
+
+	alice := newTestKey(t)
+	clock := &testClock{time.Unix(1700000000, 0)}
+	chain := newClockedTestChain(t, alice, clock.Now)
+	interval := chain.Params.RetargetInterval
+	var window []*Block
+
+	for i := 1; i < 6*interval; i++ {
+		tip, err := chain.GetBlock(chain.Tip())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bits, err := chain.NextBits(tip)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clock.Advance(time.Duration(1<<bits) * time.Second / hashRate)
+		block := mineNow(t, chain, alice)
+
+		if block.Height%interval == 0 {
+			window = nil
+		}
+
+		window = append(window, block)
+	}
+
+	if want := 5; window[0].Bits != want {
+		t.Fatalf("difficulty settled at %d bits, want %d", window[0].Bits, want)
+	}
+
+	spacing := time.Duration(window[len(window)-1].Timestamp-window[0].Timestamp) * time.Second / time.Duration(len(window)-1)
+
+	if spacing != chain.Params.TargetSpacing {
+		t.Fatalf("blocks are %s apart, want %s", spacing, chain.Params.TargetSpacing)
+	}
+}
+
+func TestValidateHeaderRejectsBadTimestamps(t *testing.T) {
+	alice := newTestKey(t)
+	clock := &testClock{time.Unix(1700000000, 0)}
+	chain := newClockedTestChain(t, alice, clock.Now)
+
+	if genesis, err := chain.GetBlock(chain.Tip()); err != nil || genesis.Timestamp != clock.Now().Unix() {
+		t.Fatalf("genesis timestamp does not come from the chain clock (%v)", err)
+	}
+
+	for i := 0; i < medianTimeSpan; i++ {
+		clock.Advance(time.Second)
+		mineNow(t, chain, alice)
+	}
+
+	tip, err := chain.GetBlock(chain.Tip())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	median, err := chain.MedianTimePast(tip)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]int64{
+		"at the median time":     median,
+		"before the median time": median - 1,
+		"too far in the future":  clock.Now().Add(chain.Params.MaxFutureTime).Unix() + 1,
+	}
+
+	for name, timestamp := range tests {
+		t.Run(name, func(t *testing.T) {
+			block := buildBlockAt(t, chain, tip, alice, timestamp)
+			var invalid *ValidationError
+
+			if err := chain.ValidateBlock(block); !errors.As(err, &invalid) {
+				t.Fatalf("block with timestamp %d was not rejected: %v", timestamp, err)
+			}
+		})
+	}
+
+	block := buildBlockAt(t, chain, tip, alice, median+1)
+
+	if err := chain.ValidateBlock(block); err != nil {
+		t.Fatalf("block after the median time was rejected: %v", err)
+	}
+}
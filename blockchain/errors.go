@@ -0,0 +1,24 @@
+package blockchain
+
+import "errors"
+
+var (
+	ErrChainNotFound       = errors.New("no existing blockchain found")
+	ErrChainExists         = errors.New("blockchain already exists")
+	ErrInsufficientFunds   = errors.New("not enough funds")
+	ErrInvalidSignature    = errors.New("invalid transaction signature")
+	ErrBlockNotFound       = errors.New("block does not exist")
+	ErrTxNotFound          = errors.New("transaction does not exist")
+	ErrBlockExists         = errors.New("block already exists")
+	ErrOrphanBlock         = errors.New("previous block is unknown")
+	ErrAlreadyInPool       = errors.New("transaction is already in the mempool")
+	ErrConflictingSpend    = errors.New("transaction spends an output already spent by a pending transaction")
+	ErrNonceSpaceExhausted = errors.New("nonce space exhausted without finding a valid hash")
+	ErrUnknownNetwork      = errors.New("unknown network")
+	ErrWrongNetwork        = errors.New("blockchain belongs to a different network")
+	ErrImmatureCoinbase    = errors.New("coinbase output is not mature yet")
+	ErrNotASigner          = errors.New("key cannot sign any input of the transaction")
+	ErrNonFinal            = errors.New("transaction is not final yet")
+	ErrSequenceLocked      = errors.New("input is still locked by its relative lock time")
+	ErrInvalidTransaction  = errors.New("invalid transaction")
+)
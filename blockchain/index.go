@@ -0,0 +1,100 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const heightPrefix = "height-"
+
+func heightKey(height int) []byte {
+	return binary.BigEndian.AppendUint32([]byte(heightPrefix), uint32(height))
+}
+
+func (chain *Blockchain) GetBlockHash(height int) ([]byte, error) {
+	var hash []byte
+
+	if height < 0 {
+		return nil, fmt.Errorf("no block at height %d: %w", height, ErrBlockNotFound)
+	}
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(heightKey(height))
+
+		if err != nil {
+			return err
+		}
+
+		hash, err = item.ValueCopy(nil)
+
+		return err
+	})
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, fmt.Errorf("no block at height %d: %w", height, ErrBlockNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+func (chain *Blockchain) GetBlockByHeight(height int) (*Block, error) {
+	hash, err := chain.GetBlockHash(height)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return chain.GetBlock(hash)
+}
+
+func (chain *Blockchain) ReindexHeights() error {
+	if err := chain.deleteByPrefix([]byte(heightPrefix)); err != nil {
+		return err
+	}
+
+	batch := chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	iter := chain.Iterator()
+
+	for {
+		block, err := iter.Next()
+
+		if err != nil {
+			return err
+		}
+
+		if err := batch.Set(heightKey(block.Height), block.Hash); err != nil {
+			return err
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+	}
+
+	return batch.Flush()
+}
+
+func (chain *Blockchain) checkHeightIndex() error {
+	tip, err := chain.GetBlock(chain.Tip())
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := chain.GetBlockHash(tip.Height)
+
+	if errors.Is(err, ErrBlockNotFound) || (err == nil && !bytes.Equal(hash, tip.Hash)) {
+		return chain.ReindexHeights()
+	}
+
+	return err
+}
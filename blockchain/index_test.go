@@ -0,0 +1,64 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBlockLocator(t *testing.T) {
+	alice := newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, 30)
+
+	locator, err := chain.GetBlockLocator()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantHeights := []int{30, 29, 28, 27, 26, 25, 24, 23, 22, 21, 19, 15, 7, 0}
+
+	if len(locator) != len(wantHeights) {
+		t.Fatalf("locator has %d hashes, want %d", len(locator), len(wantHeights))
+	}
+
+	for i, height := range wantHeights {
+		hash, err := chain.GetBlockHash(height)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(locator[i], hash) {
+			t.Fatalf("locator entry %d is not the block at height %d", i, height)
+		}
+	}
+
+	after, err := chain.GetBlockHashesAfter([][]byte{mined[4].Hash, locator[len(locator)-1]}, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(after) != 10 || !bytes.Equal(after[0], mined[5].Hash) || !bytes.Equal(after[9], mined[14].Hash) {
+		t.Fatalf("got %d hashes after height 5, want the 10 blocks from height 6", len(after))
+	}
+}
+
+func TestBlockHashesAfterSkipsSideChains(t *testing.T) {
+	alice := newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, 5)
+	side := buildBlock(t, chain, mined[1], alice)
+	importBlock(t, chain, side)
+
+	after, err := chain.GetBlockHashesAfter([][]byte{side.Hash, mined[0].Hash}, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(after) != 4 || !bytes.Equal(after[0], mined[1].Hash) || !bytes.Equal(after[3], mined[4].Hash) {
+		t.Fatalf("got %d hashes, want the 4 main chain blocks after the fork point", len(after))
+	}
+}
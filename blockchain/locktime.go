@@ -0,0 +1,74 @@
+package blockchain
+
+const (
+	LockTimeThreshold       = 500000000
+	SequenceFinal           = 0xffffffff
+	SequenceLockDisabled    = 1 << 31
+	SequenceLockTimeType    = 1 << 22
+	SequenceLockMask        = 0xffff
+	SequenceTimeGranularity = 9
+)
+
+func (tx *Transaction) IsFinal(height int, blockTime int64) bool {
+	if tx.LockTime == 0 {
+		return true
+	}
+
+	limit := int64(height)
+
+	if tx.LockTime >= LockTimeThreshold {
+		limit = blockTime
+	}
+
+	if int64(tx.LockTime) <= limit {
+		return true
+	}
+
+	for _, in := range tx.Inputs {
+		if in.Sequence != SequenceFinal {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (in *TxInput) SequenceLockSatisfied(out UTXO, height int, blockTime int64) bool {
+	if in.Sequence&SequenceLockDisabled != 0 {
+		return true
+	}
+
+	value := int64(in.Sequence & SequenceLockMask)
+
+	if in.Sequence&SequenceLockTimeType != 0 {
+		return blockTime-out.Time >= value<<SequenceTimeGranularity
+	}
+
+	return int64(height-out.Height) >= value
+}
+
+func (tx *Transaction) checkLockTime(inIdx int, lockTime int64) bool {
+	if lockTime < 0 || (lockTime < LockTimeThreshold) != (tx.LockTime < LockTimeThreshold) {
+		return false
+	}
+
+	return lockTime <= int64(tx.LockTime) && tx.Inputs[inIdx].Sequence != SequenceFinal
+}
+
+func (tx *Transaction) checkSequence(inIdx int, sequence int64) bool {
+	if sequence < 0 {
+		return false
+	}
+
+	if sequence&SequenceLockDisabled != 0 {
+		return true
+	}
+
+	txSequence := int64(tx.Inputs[inIdx].Sequence)
+
+	if txSequence&SequenceLockDisabled != 0 || (sequence&SequenceLockTimeType) != (txSequence&SequenceLockTimeType) {
+		return false
+	}
+
+	return sequence&SequenceLockMask <= txSequence&SequenceLockMask
+}
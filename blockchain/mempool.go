@@ -0,0 +1,453 @@
+package blockchain
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dgraph-io/badger/v4"
+	"slices"
+	"sync"
+)
+
+const mempoolPrefix = "mempool-"
+
+type Mempool struct {
+	chain *Blockchain
+	mu    sync.Mutex
+	txs   map[string]*Transaction
+	order []string
+	spent map[string]string
+	fees  map[string]int
+}
+
+func NewMempool(chain *Blockchain) (*Mempool, error) {
+	pool := &Mempool{
+		chain: chain,
+		txs:   make(map[string]*Transaction),
+		spent: make(map[string]string),
+		fees:  make(map[string]int),
+	}
+
+	if err := pool.load(); err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+func (pool *Mempool) load() error {
+	var stored []*Transaction
+
+	prefix := []byte(mempoolPrefix)
+
+	err := pool.chain.Database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				tx, err := DeserializeTransaction(val)
+
+				if err != nil {
+					return err
+				}
+
+				stored = append(stored, &tx)
+
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for {
+		var rejected []*Transaction
+
+		for _, tx := range stored {
+			err := pool.add(tx)
+
+			if isRejection(err) {
+				rejected = append(rejected, tx)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if len(rejected) == len(stored) {
+			break
+		}
+
+		stored = rejected
+	}
+
+	return pool.forget(stored)
+}
+
+func isRejection(err error) bool {
+	return errors.Is(err, ErrInvalidTransaction) || errors.Is(err, ErrConflictingSpend) ||
+		errors.Is(err, ErrNonFinal) || errors.Is(err, ErrAlreadyInPool)
+}
+
+func (pool *Mempool) Add(tx *Transaction) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.add(tx)
+}
+
+func (pool *Mempool) add(tx *Transaction) error {
+	ID := hex.EncodeToString(tx.ID)
+
+	if _, ok := pool.txs[ID]; ok {
+		return ErrAlreadyInPool
+	}
+
+	if tx.IsCoinbase() {
+		return invalidTransaction(tx, "coinbase transactions cannot be submitted")
+	}
+
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return invalidTransaction(tx, "it has a wrong ID")
+	}
+
+	for _, in := range tx.Inputs {
+		if _, ok := pool.spent[outpoint(in.ID, in.Out)]; ok {
+			return ErrConflictingSpend
+		}
+	}
+
+	height, err := pool.chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	now := pool.chain.now().Unix()
+
+	if !tx.IsFinal(height+1, now) {
+		return fmt.Errorf("transaction %x is locked until %d: %w", tx.ID, tx.LockTime, ErrNonFinal)
+	}
+
+	view := newMemoryView(pool.chain)
+
+	for _, pendingID := range pool.order {
+		view.apply(pool.txs[pendingID], height+1, now)
+	}
+
+	fee, err := pool.chain.validateTransaction(tx, view, height+1, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = pool.chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set(append([]byte(mempoolPrefix), tx.ID...), tx.Serialize())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	pool.txs[ID] = tx
+	pool.fees[ID] = fee
+	pool.order = append(pool.order, ID)
+
+	for _, in := range tx.Inputs {
+		pool.spent[outpoint(in.ID, in.Out)] = ID
+	}
+
+	return nil
+}
+
+func (pool *Mempool) Get(ID []byte) (*Transaction, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx, ok := pool.txs[hex.EncodeToString(ID)]
+
+	return tx, ok
+}
+
+func (pool *Mempool) IsSpent(txID []byte, outIdx int) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	_, ok := pool.spent[outpoint(txID, outIdx)]
+
+	return ok
+}
+
+func (pool *Mempool) Len() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return len(pool.order)
+}
+
+func (pool *Mempool) Transactions() []*Transaction {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	txs := make([]*Transaction, 0, len(pool.order))
+
+	for _, ID := range pool.order {
+		txs = append(txs, pool.txs[ID])
+	}
+
+	return txs
+}
+
+func (pool *Mempool) Fee(ID []byte) (int, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	fee, ok := pool.fees[hex.EncodeToString(ID)]
+
+	return fee, ok
+}
+
+func (pool *Mempool) SelectTransactions(maxSize int) ([]*Transaction, int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	candidates := slices.Clone(pool.order)
+
+	slices.SortStableFunc(candidates, func(a, b string) int {
+		return cmp.Compare(pool.fees[b]*pool.txs[a].Size(), pool.fees[a]*pool.txs[b].Size())
+	})
+
+	var selected []*Transaction
+
+	included := make(map[string]bool)
+	size, fees := 0, 0
+
+	for added := true; added; {
+		added = false
+
+		for _, ID := range candidates {
+			tx := pool.txs[ID]
+
+			if included[ID] || size+tx.Size() > maxSize || !pool.parentsIncluded(tx, included) {
+				continue
+			}
+
+			selected = append(selected, tx)
+			included[ID] = true
+			size += tx.Size()
+			fees += pool.fees[ID]
+			added = true
+		}
+	}
+
+	return selected, fees
+}
+
+func (pool *Mempool) parentsIncluded(tx *Transaction, included map[string]bool) bool {
+	for _, in := range tx.Inputs {
+		parentID := hex.EncodeToString(in.ID)
+
+		if _, pending := pool.txs[parentID]; pending && !included[parentID] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (pool *Mempool) ApplyChainUpdate(update *ChainUpdate) error {
+	var candidates []*Transaction
+
+	for i := len(update.Disconnected) - 1; i >= 0; i-- {
+		for _, tx := range update.Disconnected[i].Transactions {
+			if !tx.IsCoinbase() {
+				candidates = append(candidates, tx)
+			}
+		}
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for _, ID := range pool.order {
+		candidates = append(candidates, pool.txs[ID])
+	}
+
+	pool.txs = make(map[string]*Transaction)
+	pool.spent = make(map[string]string)
+	pool.fees = make(map[string]int)
+	pool.order = nil
+
+	var rejected []*Transaction
+
+	for _, tx := range candidates {
+		err := pool.add(tx)
+
+		if isRejection(err) {
+			rejected = append(rejected, tx)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return pool.forget(rejected)
+}
+
+func (pool *Mempool) forget(txs []*Transaction) error {
+	batch := pool.chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	for _, tx := range txs {
+		if _, ok := pool.txs[hex.EncodeToString(tx.ID)]; ok {
+			continue
+		}
+
+		if err := batch.Delete(append([]byte(mempoolPrefix), tx.ID...)); err != nil {
+			return err
+		}
+	}
+
+	return batch.Flush()
+}
+
+func (pool *Mempool) RemoveConfirmed(block *Block) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	evicted := make(map[string]bool)
+
+	for _, tx := range block.Transactions {
+		evicted[hex.EncodeToString(tx.ID)] = true
+
+		if tx.IsCoinbase() {
+			continue
+		}
+
+		for _, in := range tx.Inputs {
+			if ID, ok := pool.spent[outpoint(in.ID, in.Out)]; ok {
+				evicted[ID] = true
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, ID := range pool.order {
+			if evicted[ID] {
+				continue
+			}
+
+			for _, in := range pool.txs[ID].Inputs {
+				parentID := hex.EncodeToString(in.ID)
+
+				if evicted[parentID] && pool.txs[parentID] != nil && !containsTx(block, parentID) {
+					evicted[ID] = true
+					changed = true
+
+					break
+				}
+			}
+		}
+	}
+
+	return pool.evict(evicted)
+}
+
+func containsTx(block *Block, ID string) bool {
+	for _, tx := range block.Transactions {
+		if hex.EncodeToString(tx.ID) == ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pool *Mempool) evict(evicted map[string]bool) error {
+	var order []string
+
+	batch := pool.chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	for _, ID := range pool.order {
+		if !evicted[ID] {
+			order = append(order, ID)
+
+			continue
+		}
+
+		for _, in := range pool.txs[ID].Inputs {
+			delete(pool.spent, outpoint(in.ID, in.Out))
+		}
+
+		if err := batch.Delete(append([]byte(mempoolPrefix), pool.txs[ID].ID...)); err != nil {
+			return err
+		}
+
+		delete(pool.txs, ID)
+		delete(pool.fees, ID)
+	}
+
+	pool.order = order
+
+	return batch.Flush()
+}
+
+func (chain *Blockchain) MinePending(ctx context.Context, pool *Mempool, address string, maxBlockSize int) (*Block, error) {
+	height, err := chain.GetBestHeight()
+
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := CoinbaseTx(chain.Params, address, "", height+1, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := chain.Params.MaxBlockSize
+
+	if maxBlockSize > 0 {
+		maxSize = min(maxSize, maxBlockSize)
+	}
+
+	selected, fees := pool.SelectTransactions(maxSize - reserved.Size() - binary.MaxVarintLen64)
+	coinbase, err := CoinbaseTx(chain.Params, address, "", height+1, fees)
+
+	if err != nil {
+		return nil, err
+	}
+
+	txs := append([]*Transaction{coinbase}, selected...)
+
+	block, err := chain.MineBlock(ctx, txs)
+
+	if err != nil {
+		return nil, fmt.Errorf("mining pending transactions: %w", err)
+	}
+
+	if bytes.Equal(chain.Tip(), block.Hash) {
+		if err := pool.RemoveConfirmed(block); err != nil {
+			return nil, err
+		}
+	}
+
+	return block, nil
+}
@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"github.com/dgraph-io/badger/v4"
+	"testing"
+)
+
+func TestFindSpendableOutputsSkipsMempoolSpends(t *testing.T) {
+	alice, bob := newTestKey(t), newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, chain.Params.CoinbaseMaturity+1)
+
+	pool, err := NewMempool(chain)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toBob := spend(t, alice, mined[0].Transactions[0], 0, bob, 30, 1)
+
+	if err := pool.Add(toBob); err != nil {
+		t.Fatal(err)
+	}
+
+	_, outputs, err := chain.FindSpendableOutputs(alice.lockingScript, 1<<30, pool)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := outputs[hex.EncodeToString(mined[0].Transactions[0].ID)]; ok {
+		t.Fatal("an output spent by a mempool transaction was selected")
+	}
+
+	next, err := NewUnsignedTransaction(alice.lockingScript, bob.address, 30, 1, 0, chain, pool)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.SignTransaction(next, alice.privKey); err != nil {
+		t.Fatal(err)
+	}
+
+	next.ID = next.Hash()
+
+	if err := pool.Add(next); err != nil {
+		t.Fatalf("second unconfirmed spend was rejected: %v", err)
+	}
+}
+
+func TestLoadKeepsValidTransactionsAndDropsRejectedOnes(t *testing.T) {
+	alice, bob := newTestKey(t), newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, chain.Params.CoinbaseMaturity+1)
+
+	pool, err := NewMempool(chain)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toBob := spend(t, alice, mined[0].Transactions[0], 0, bob, 30, 1)
+	fromBob := spend(t, bob, toBob, 0, alice, 20, 1)
+
+	for _, tx := range []*Transaction{toBob, fromBob} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invalid := spend(t, alice, mined[1].Transactions[0], 0, bob, 30, 1)
+	invalid.Outputs[0].Value = 1000
+	invalid.ID = invalid.Hash()
+	invalidKey := append([]byte(mempoolPrefix), invalid.ID...)
+
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set(invalidKey, invalid.Serialize())
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewMempool(chain)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.Len() != 2 {
+		t.Fatalf("reloaded mempool has %d transactions, want 2", reloaded.Len())
+	}
+
+	var exists bool
+
+	err = chain.Database.View(func(txn *badger.Txn) error {
+		exists, err = hasKey(txn, string(invalidKey))
+
+		return err
+	})
+
+	if err != nil || exists {
+		t.Fatalf("the rejected transaction is still persisted (%v)", err)
+	}
+}
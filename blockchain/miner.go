@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Miner struct {
+	Workers        int
+	MaxNonce       int
+	ReportInterval time.Duration
+	OnHashrate     func(hashesPerSecond float64)
+}
+
+type solution struct {
+	nonce int
+	hash  []byte
+}
+
+func NewMiner(workers int) *Miner {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Miner{Workers: workers, MaxNonce: math.MaxInt64, ReportInterval: time.Second}
+}
+
+func (miner *Miner) Mine(ctx context.Context, pow *ProofOfWork) (int, []byte, error) {
+	var hashes atomic.Int64
+	var wg sync.WaitGroup
+
+	workers := max(miner.Workers, 1)
+	maxNonce := miner.MaxNonce
+
+	if maxNonce <= 0 {
+		maxNonce = math.MaxInt64
+	}
+
+	miningCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan solution, 1)
+
+	for start := 0; start < workers; start++ {
+		wg.Add(1)
+
+		go func(nonce int) {
+			defer wg.Done()
+
+			var intHash big.Int
+
+			for attempts := 0; ; attempts++ {
+				if attempts%1024 == 0 && miningCtx.Err() != nil {
+					return
+				}
+
+				hash := sha256.Sum256(pow.InitData(nonce))
+				hashes.Add(1)
+				intHash.SetBytes(hash[:])
+
+				if intHash.Cmp(pow.Target) == -1 {
+					select {
+					case found <- solution{nonce, hash[:]}:
+						cancel()
+					default:
+					}
+
+					return
+				}
+
+				if nonce > maxNonce-workers {
+					return
+				}
+
+				nonce += workers
+			}
+		}(start)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	miner.reportHashrate(done, &hashes)
+
+	select {
+	case s := <-found:
+		return s.nonce, s.hash, nil
+	default:
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	return 0, nil, ErrNonceSpaceExhausted
+}
+
+func (miner *Miner) reportHashrate(done <-chan struct{}, hashes *atomic.Int64) {
+	if miner.OnHashrate == nil || miner.ReportInterval <= 0 {
+		<-done
+
+		return
+	}
+
+	ticker := time.NewTicker(miner.ReportInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	lastCount := int64(0)
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			count := hashes.Load()
+			miner.OnHashrate(float64(count-lastCount) / now.Sub(last).Seconds())
+			last, lastCount = now, count
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/script"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"slices"
+)
+
+func MultiSigAddress(params *Params, redeemScript []byte) (string, error) {
+	if _, _, ok := script.ExtractMultiSig(redeemScript); !ok {
+		return "", fmt.Errorf("%w: not a multisig script", script.ErrInvalidScript)
+	}
+
+	if len(redeemScript) > script.MaxElementSize {
+		return "", fmt.Errorf("%w: redeem script is %d bytes, the limit is %d", script.ErrInvalidScript, len(redeemScript), script.MaxElementSize)
+	}
+
+	return string(wallet.EncodeAddress(script.Hash160(redeemScript), params.ScriptVersion)), nil
+}
+
+func (chain *Blockchain) SignMultiSigTransaction(tx *Transaction, privKey ecdsa.PrivateKey, redeemScript []byte) error {
+	prevTxs, err := chain.findPrevTransactions(tx)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.SignMultiSig(privKey, redeemScript, prevTxs)
+}
+
+func (tx *Transaction) SignMultiSig(privKey ecdsa.PrivateKey, redeemScript []byte, prevTXs map[string]Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
+
+	pubKey := publicKeyBytes(privKey)
+	signed := false
+
+	for inId, in := range tx.Inputs {
+		prevOut, err := prevOutput(prevTXs, in)
+
+		if err != nil {
+			return err
+		}
+
+		scriptCode, payToScriptHash := prevOut.Script, false
+
+		if scriptHash, ok := script.ExtractScriptHash(prevOut.Script); ok {
+			if !bytes.Equal(scriptHash, script.Hash160(redeemScript)) {
+				continue
+			}
+
+			scriptCode, payToScriptHash = redeemScript, true
+		}
+
+		required, pubKeys, ok := script.ExtractMultiSig(scriptCode)
+
+		if !ok {
+			continue
+		}
+
+		keyIdx := slices.IndexFunc(pubKeys, func(key []byte) bool {
+			return bytes.Equal(key, pubKey)
+		})
+
+		if keyIdx < 0 {
+			continue
+		}
+
+		signatures := tx.multiSigSignatures(inId, scriptCode, pubKeys)
+
+		if signatures[keyIdx] == nil {
+			signature, err := signHash(privKey, tx.signatureHash(inId, scriptCode))
+
+			if err != nil {
+				return err
+			}
+
+			signatures[keyIdx] = signature
+		}
+
+		var unlocking []byte
+
+		for _, signature := range signatures {
+			if signature != nil && required > 0 {
+				unlocking = script.AddData(unlocking, signature)
+				required--
+			}
+		}
+
+		if payToScriptHash {
+			unlocking = script.AddData(unlocking, redeemScript)
+		}
+
+		tx.Inputs[inId].Script = unlocking
+		signed = true
+	}
+
+	if !signed {
+		return ErrNotASigner
+	}
+
+	tx.ID = tx.Hash()
+
+	return nil
+}
+
+func (tx *Transaction) multiSigSignatures(inIdx int, scriptCode []byte, pubKeys [][]byte) [][]byte {
+	signatures := make([][]byte, len(pubKeys))
+	checker := &inputChecker{tx, inIdx}
+	pushed, err := script.PushedData(tx.Inputs[inIdx].Script)
+
+	if err != nil {
+		return signatures
+	}
+
+	for _, signature := range pushed {
+		for keyIdx, pubKey := range pubKeys {
+			if signatures[keyIdx] == nil && checker.CheckSignature(signature, pubKey, scriptCode) {
+				signatures[keyIdx] = signature
+
+				break
+			}
+		}
+	}
+
+	return signatures
+}
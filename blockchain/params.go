@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+type Params struct {
+	Name             string
+	DataSubdir       string
+	GenesisData      string
+	AddressVersion   byte
+	ScriptVersion    byte
+	InitialBits      int
+	MinBits          int
+	MaxBits          int
+	RetargetInterval int
+	TargetSpacing    time.Duration
+	MaxFutureTime    time.Duration
+	MaxBlockSize     int
+	InitialSubsidy   int
+	HalvingInterval  int
+	CoinbaseMaturity int
+}
+
+var MainNetParams = Params{
+	Name:             "main",
+	DataSubdir:       "",
+	GenesisData:      "This is where it all started",
+	AddressVersion:   0x00,
+	ScriptVersion:    0x05,
+	InitialBits:      18,
+	MinBits:          1,
+	MaxBits:          240,
+	RetargetInterval: 16,
+	TargetSpacing:    10 * time.Second,
+	MaxFutureTime:    time.Minute,
+	MaxBlockSize:     1 << 20,
+	InitialSubsidy:   100,
+	HalvingInterval:  2100,
+	CoinbaseMaturity: 100,
+}
+
+var TestNetParams = Params{
+	Name:             "test",
+	DataSubdir:       "test",
+	GenesisData:      "This is where testing started",
+	AddressVersion:   0x6f,
+	ScriptVersion:    0xc4,
+	InitialBits:      16,
+	MinBits:          1,
+	MaxBits:          240,
+	RetargetInterval: 16,
+	TargetSpacing:    10 * time.Second,
+	MaxFutureTime:    time.Minute,
+	MaxBlockSize:     1 << 20,
+	InitialSubsidy:   100,
+	HalvingInterval:  2100,
+	CoinbaseMaturity: 100,
+}
+
+var RegTestParams = Params{
+	Name:             "regtest",
+	DataSubdir:       "regtest",
+	GenesisData:      "This is where regression testing started",
+	AddressVersion:   0x3c,
+	ScriptVersion:    0x7a,
+	InitialBits:      8,
+	MinBits:          1,
+	MaxBits:          240,
+	RetargetInterval: 16,
+	TargetSpacing:    time.Second,
+	MaxFutureTime:    time.Minute,
+	MaxBlockSize:     1 << 20,
+	InitialSubsidy:   100,
+	HalvingInterval:  150,
+	CoinbaseMaturity: 10,
+}
+
+var networks = []*Params{&MainNetParams, &TestNetParams, &RegTestParams}
+
+func NetworkParams(name string) (*Params, error) {
+	for _, params := range networks {
+		if params.Name == name {
+			return params, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownNetwork, name)
+}
+
+func (params *Params) Subsidy(height int) int {
+	halvings := height / params.HalvingInterval
+
+	if halvings >= 63 {
+		return 0
+	}
+
+	return params.InitialSubsidy >> halvings
+}
+
+func (params *Params) IssuedAt(height int) int {
+	issued := 0
+
+	for start := 0; start <= height; start += params.HalvingInterval {
+		subsidy := params.Subsidy(start)
+
+		if subsidy == 0 {
+			break
+		}
+
+		issued += subsidy * min(params.HalvingInterval, height-start+1)
+	}
+
+	return issued
+}
+
+func (params *Params) MaxSupply() int {
+	supply := 0
+
+	for subsidy := params.InitialSubsidy; subsidy > 0; subsidy >>= 1 {
+		supply += subsidy * params.HalvingInterval
+	}
+
+	return supply
+}
+
+func (params *Params) DataDir(root string) string {
+	return filepath.Join(root, params.DataSubdir)
+}
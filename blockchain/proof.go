@@ -1,75 +1,41 @@
 package blockchain
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
-	"fmt"
-	"log"
-	"math"
 	"math/big"
 )
 
-const Difficulty = 18
-
 type ProofOfWork struct {
-	Block  *Block
+	Header *BlockHeader
+	Bits   int
 	Target *big.Int
 }
 
-func NewProof(b *Block) *ProofOfWork {
+func NewProof(b *Block, bits int) *ProofOfWork {
 	target := big.NewInt(1)
-	target.Lsh(target, uint(256-Difficulty))
+	target.Lsh(target, uint(256-bits))
 
-	pow := &ProofOfWork{b, target}
+	pow := &ProofOfWork{&b.BlockHeader, bits, target}
 
 	return pow
 }
 
-func (pow *ProofOfWork) Run() (int, []byte) {
-	var intHash big.Int
-	var hash [32]byte
-
-	nonce := 0
-
-	for nonce < math.MaxInt64 {
-		data := pow.InitData(nonce)
-		hash = sha256.Sum256(data)
-
-		fmt.Printf("\r%x", hash)
-
-		intHash.SetBytes(hash[:])
-
-		if intHash.Cmp(pow.Target) == -1 {
-			break
-		}
-
-		nonce++
-	}
-
-	fmt.Println()
-
-	return nonce, hash[:]
-}
-
 func (pow *ProofOfWork) InitData(nonce int) []byte {
-	data := bytes.Join(
-		[][]byte{
-			pow.Block.PrevHash,
-			pow.Block.HashTransactions(),
-			toHex(int64(nonce)),
-			toHex(Difficulty),
-		},
-		[]byte{},
-	)
+	header := *pow.Header
+	header.Nonce = nonce
 
-	return data
+	return header.Serialize()
 }
 
 func (pow *ProofOfWork) Validate() bool {
 	var intHash big.Int
 
-	data := pow.InitData(pow.Block.Nonce)
+	if pow.Header.Bits != pow.Bits {
+		return false
+	}
+
+	data := pow.InitData(pow.Header.Nonce)
 	hash := sha256.Sum256(data)
 	intHash.SetBytes(hash[:])
 
@@ -77,10 +43,5 @@ func (pow *ProofOfWork) Validate() bool {
 }
 
 func toHex(num int64) []byte {
-	buff := new(bytes.Buffer)
-	if err := binary.Write(buff, binary.BigEndian, num); err != nil {
-		log.Panic(err)
-	}
-
-	return buff.Bytes()
+	return binary.BigEndian.AppendUint64(nil, uint64(num))
 }
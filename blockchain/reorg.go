@@ -0,0 +1,351 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/dgraph-io/badger/v4"
+	"log"
+	"math/big"
+)
+
+const (
+	workPrefix = "work-"
+	undoPrefix = "undo-"
+)
+
+type ChainUpdate struct {
+	Connected    []*Block
+	Disconnected []*Block
+}
+
+type spentOutput struct {
+	ID     []byte
+	Out    int
+	Output UTXO
+}
+
+func blockWork(bits int) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits))
+}
+
+func (chain *Blockchain) GetChainWork(hash []byte) (*big.Int, error) {
+	work := new(big.Int)
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(append([]byte(workPrefix), hash...))
+
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			work.SetBytes(val)
+
+			return nil
+		})
+	})
+
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	return work, nil
+}
+
+func (chain *Blockchain) ImportBlock(block *Block) (*ChainUpdate, error) {
+	exists, err := chain.HasBlock(block.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, ErrBlockExists
+	}
+
+	if len(block.PrevHash) == 0 {
+		return nil, ErrOrphanBlock
+	}
+
+	if exists, err = chain.HasBlock(block.PrevHash); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrOrphanBlock
+	}
+
+	if err := chain.validateHeader(block); err != nil {
+		return nil, err
+	}
+
+	work, err := chain.GetChainWork(block.PrevHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	work.Add(work, blockWork(block.Bits))
+
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(block.Hash, block.Serialize()); err != nil {
+			return err
+		}
+
+		return txn.Set(append([]byte(workPrefix), block.Hash...), work.Bytes())
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	tipWork, err := chain.GetChainWork(chain.Tip())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if work.Cmp(tipWork) <= 0 {
+		return &ChainUpdate{}, nil
+	}
+
+	return chain.reorganize(block)
+}
+
+func (chain *Blockchain) findFork(newTip *Block) (*ChainUpdate, error) {
+	update := &ChainUpdate{}
+
+	oldTip, err := chain.GetBlock(chain.Tip())
+
+	if err != nil {
+		return nil, err
+	}
+
+	branch := newTip
+
+	for !bytes.Equal(oldTip.Hash, branch.Hash) {
+		if oldTip.Height >= branch.Height {
+			update.Disconnected = append(update.Disconnected, oldTip)
+
+			if oldTip, err = chain.GetBlock(oldTip.PrevHash); err != nil {
+				return nil, err
+			}
+		} else {
+			update.Connected = append(update.Connected, branch)
+
+			if branch, err = chain.GetBlock(branch.PrevHash); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, j := 0, len(update.Connected)-1; i < j; i, j = i+1, j-1 {
+		update.Connected[i], update.Connected[j] = update.Connected[j], update.Connected[i]
+	}
+
+	return update, nil
+}
+
+func (chain *Blockchain) reorganize(newTip *Block) (*ChainUpdate, error) {
+	update, err := chain.findFork(newTip)
+
+	if err != nil {
+		return nil, err
+	}
+
+	view := newMemoryView(chain)
+
+	disconnectedUndos := make([][]spentOutput, len(update.Disconnected))
+
+	for i, block := range update.Disconnected {
+		undo, err := chain.getUndo(block.Hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		view.disconnect(block, undo)
+		disconnectedUndos[i] = undo
+	}
+
+	undos := make([][]spentOutput, len(update.Connected))
+
+	for i, block := range update.Connected {
+		if err := chain.validateBlock(block, view); err != nil {
+			var validationErr *ValidationError
+
+			if !errors.As(err, &validationErr) {
+				return nil, err
+			}
+
+			for _, invalid := range update.Connected[i:] {
+				if err := chain.deleteBlock(invalid.Hash); err != nil {
+					return nil, err
+				}
+			}
+
+			return nil, err
+		}
+
+		if undos[i], err = view.connect(block); err != nil {
+			return nil, err
+		}
+	}
+
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		if err := view.flush(txn); err != nil {
+			return err
+		}
+
+		for i, block := range update.Disconnected {
+			if err := txn.Delete(append([]byte(undoPrefix), block.Hash...)); err != nil {
+				return err
+			}
+
+			if err := unindexAddresses(txn, chain.Params, block, disconnectedUndos[i]); err != nil {
+				return err
+			}
+
+			if err := txn.Delete(heightKey(block.Height)); err != nil {
+				return err
+			}
+
+			if chain.TxIndex {
+				if err := unindexTransactions(txn, block); err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, block := range update.Connected {
+			if err := txn.Set(append([]byte(undoPrefix), block.Hash...), serializeUndo(undos[i])); err != nil {
+				return err
+			}
+
+			if err := txn.Set(heightKey(block.Height), block.Hash); err != nil {
+				return err
+			}
+
+			if err := indexAddresses(txn, chain.Params, block, undos[i]); err != nil {
+				return err
+			}
+
+			if chain.TxIndex {
+				if err := indexTransactions(txn, block); err != nil {
+					return err
+				}
+			}
+		}
+
+		return txn.Set([]byte("lh"), newTip.Hash)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	chain.setTip(newTip.Hash)
+
+	return update, nil
+}
+
+func (chain *Blockchain) deleteBlock(hash []byte) error {
+	return chain.Database.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(hash); err != nil {
+			return err
+		}
+
+		return txn.Delete(append([]byte(workPrefix), hash...))
+	})
+}
+
+func (chain *Blockchain) getUndo(hash []byte) ([]spentOutput, error) {
+	var undo []spentOutput
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(append([]byte(undoPrefix), hash...))
+
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return gob.NewDecoder(bytes.NewReader(val)).Decode(&undo)
+		})
+	})
+
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	return undo, nil
+}
+
+func serializeUndo(undo []spentOutput) []byte {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(undo); err != nil {
+		log.Panic(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func (view *memoryView) connect(block *Block) ([]spentOutput, error) {
+	var undo []spentOutput
+
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Inputs {
+				out, _, err := view.GetUTXO(in.ID, in.Out)
+
+				if err != nil {
+					return nil, err
+				}
+
+				undo = append(undo, spentOutput{in.ID, in.Out, out})
+			}
+		}
+
+		view.apply(tx, block.Height, block.Timestamp)
+	}
+
+	return undo, nil
+}
+
+func (view *memoryView) disconnect(block *Block, undo []spentOutput) {
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		tx := block.Transactions[i]
+
+		for outIdx := range tx.Outputs {
+			view.remove(tx.ID, outIdx)
+		}
+
+		if tx.IsCoinbase() {
+			continue
+		}
+
+		for j := len(tx.Inputs) - 1; j >= 0 && len(undo) > 0; j-- {
+			spent := undo[len(undo)-1]
+			undo = undo[:len(undo)-1]
+			view.add(spent.ID, spent.Out, spent.Output)
+		}
+	}
+}
+
+func (view *memoryView) flush(txn *badger.Txn) error {
+	for key := range view.removed {
+		if err := txn.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+
+	for key, out := range view.added {
+		if err := txn.Set([]byte(key), out.Serialize()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
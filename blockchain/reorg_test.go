@@ -0,0 +1,104 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReorganizeToHeavierBranch(t *testing.T) {
+	alice, bob, carol, dave := newTestKey(t), newTestKey(t), newTestKey(t), newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, chain.Params.CoinbaseMaturity+1)
+	fork := mined[len(mined)-1]
+
+	pool, err := NewMempool(chain)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toBob := spend(t, alice, mined[0].Transactions[0], 0, bob, 30, 1)
+
+	if err := pool.Add(toBob); err != nil {
+		t.Fatal(err)
+	}
+
+	light := buildBlock(t, chain, fork, alice, toBob)
+
+	if err := pool.ApplyChainUpdate(importBlock(t, chain, light)); err != nil {
+		t.Fatal(err)
+	}
+
+	if pool.Len() != 0 || balance(t, chain, bob) != 30 {
+		t.Fatalf("light branch not applied: mempool has %d transactions, bob has %d", pool.Len(), balance(t, chain, bob))
+	}
+
+	toCarol := spend(t, alice, mined[1].Transactions[0], 0, carol, 40, 1)
+	toDave := spend(t, carol, toCarol, 0, dave, 25, 1)
+	heavy1 := buildBlock(t, chain, fork, alice, toCarol)
+	heavy2 := buildBlock(t, chain, heavy1, alice, toDave)
+
+	if update := importBlock(t, chain, heavy1); len(update.Connected) != 0 {
+		t.Fatal("a branch with equal work replaced the tip")
+	}
+
+	update := importBlock(t, chain, heavy2)
+
+	if len(update.Disconnected) != 1 || len(update.Connected) != 2 {
+		t.Fatalf("got %d disconnected and %d connected blocks, want 1 and 2", len(update.Disconnected), len(update.Connected))
+	}
+
+	if err := pool.ApplyChainUpdate(update); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(chain.Tip(), heavy2.Hash) {
+		t.Fatalf("tip is %x, want %x", chain.Tip(), heavy2.Hash)
+	}
+
+	if height, err := chain.GetBestHeight(); err != nil || height != fork.Height+2 {
+		t.Fatalf("best height is %d, want %d (%v)", height, fork.Height+2, err)
+	}
+
+	for key, want := range map[*testKey]int{bob: 0, carol: 14, dave: 25} {
+		if got := balance(t, chain, key); got != want {
+			t.Errorf("balance of %s is %d, want %d", key.address, got, want)
+		}
+	}
+
+	if _, ok := pool.Get(toBob.ID); !ok || pool.Len() != 1 {
+		t.Fatalf("orphaned transaction was not returned to the mempool, which has %d transactions", pool.Len())
+	}
+
+	if exists, err := chain.HasBlock(light.Hash); err != nil || !exists {
+		t.Fatal("the disconnected block was deleted")
+	}
+}
+
+func TestReorganizeRejectsInvalidBranch(t *testing.T) {
+	alice, bob := newTestKey(t), newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, chain.Params.CoinbaseMaturity+1)
+	fork := mined[len(mined)-1]
+	tip := mineBlocks(t, chain, alice, 1)[0]
+
+	toBob := spend(t, alice, mined[0].Transactions[0], 0, bob, 30, 1)
+	toBob.Outputs[0].Value = 1000
+	toBob.ID = toBob.Hash()
+	branch1 := buildBlock(t, chain, fork, alice)
+	branch2 := buildBlock(t, chain, branch1, alice, toBob)
+
+	importBlock(t, chain, branch1)
+
+	if _, err := chain.ImportBlock(branch2); err == nil {
+		t.Fatal("a branch with an invalid transaction was accepted")
+	}
+
+	if !bytes.Equal(chain.Tip(), tip.Hash) {
+		t.Fatalf("tip is %x, want %x", chain.Tip(), tip.Hash)
+	}
+
+	if exists, err := chain.HasBlock(branch2.Hash); err != nil || exists {
+		t.Fatal("the invalid block was kept")
+	}
+}
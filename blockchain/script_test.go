@@ -0,0 +1,340 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/e-aleixandre/go-blockchain/script"
+	"testing"
+)
+
+type scriptTest struct {
+	name     string
+	locking  []byte
+	lockTime int
+	sequence uint32
+	unlock   func(t *testing.T, tx *Transaction) []byte
+	err      error
+}
+
+func runScriptTests(t *testing.T, tests []scriptTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prevTx := Transaction{nil, nil, []TxOutput{{50, test.locking}}, 0}
+			prevTx.ID = prevTx.Hash()
+
+			tx := &Transaction{nil, []TxInput{{prevTx.ID, 0, nil, test.sequence}}, []TxOutput{{40, nil}}, test.lockTime}
+			tx.Inputs[0].Script = test.unlock(t, tx)
+			tx.ID = tx.Hash()
+
+			err := tx.Verify(prevTx.Outputs)
+
+			if test.err == nil && err != nil {
+				t.Fatalf("spend was rejected: %v", err)
+			}
+
+			if test.err != nil && (!errors.Is(err, ErrInvalidSignature) || !errors.Is(err, test.err)) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func signInput(t *testing.T, key *testKey, tx *Transaction, scriptCode []byte) []byte {
+	t.Helper()
+
+	signature, err := signHash(key.privKey, tx.signatureHash(0, scriptCode))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signature
+}
+
+func TestPayToPubKeyHashScripts(t *testing.T) {
+	alice, bob := newTestKey(t), newTestKey(t)
+	nullData, err := script.NullData([]byte("memo"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runScriptTests(t, []scriptTest{
+		{
+			name:     "signed by the owner",
+			locking:  alice.lockingScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.SignatureScript(signInput(t, alice, tx, alice.lockingScript), publicKeyBytes(alice.privKey))
+			},
+		},
+		{
+			name:     "signed by another key",
+			locking:  alice.lockingScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.SignatureScript(signInput(t, bob, tx, alice.lockingScript), publicKeyBytes(bob.privKey))
+			},
+			err: script.ErrVerifyFailed,
+		},
+		{
+			name:     "owner key with a signature from another key",
+			locking:  alice.lockingScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.SignatureScript(signInput(t, bob, tx, alice.lockingScript), publicKeyBytes(alice.privKey))
+			},
+			err: script.ErrScriptFailed,
+		},
+		{
+			name:     "signature over another script",
+			locking:  alice.lockingScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.SignatureScript(signInput(t, alice, tx, bob.lockingScript), publicKeyBytes(alice.privKey))
+			},
+			err: script.ErrScriptFailed,
+		},
+		{
+			name:     "unlocking script with opcodes",
+			locking:  alice.lockingScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				signatureScript := script.SignatureScript(signInput(t, alice, tx, alice.lockingScript), publicKeyBytes(alice.privKey))
+
+				return script.AddOp(signatureScript, script.OP_NOP)
+			},
+			err: script.ErrPushOnly,
+		},
+		{
+			name:     "null data output",
+			locking:  nullData,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.AddInt(nil, 1)
+			},
+			err: script.ErrEarlyReturn,
+		},
+	})
+}
+
+func TestMultiSigScripts(t *testing.T) {
+	keys := []*testKey{newTestKey(t), newTestKey(t), newTestKey(t)}
+	outsider := newTestKey(t)
+	pubKeys := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		pubKeys[i] = publicKeyBytes(key.privKey)
+	}
+
+	redeemScript, err := script.MultiSig(2, pubKeys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherRedeemScript, err := script.MultiSig(1, pubKeys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payToScriptHash := script.PayToScriptHash(script.Hash160(redeemScript))
+
+	signatures := func(t *testing.T, tx *Transaction, scriptCode []byte, signers ...*testKey) []byte {
+		var unlocking []byte
+
+		for _, signer := range signers {
+			unlocking = script.AddData(unlocking, signInput(t, signer, tx, scriptCode))
+		}
+
+		return unlocking
+	}
+
+	signMultiSig := func(t *testing.T, tx *Transaction, locking []byte, signers ...*testKey) []byte {
+		prevTxs := map[string]Transaction{hex.EncodeToString(tx.Inputs[0].ID): {tx.Inputs[0].ID, nil, []TxOutput{{50, locking}}, 0}}
+
+		for _, signer := range signers {
+			if err := tx.SignMultiSig(signer.privKey, redeemScript, prevTxs); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return tx.Inputs[0].Script
+	}
+
+	runScriptTests(t, []scriptTest{
+		{
+			name:     "bare 2 of 3 in key order",
+			locking:  redeemScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signatures(t, tx, redeemScript, keys[0], keys[2])
+			},
+		},
+		{
+			name:     "bare 2 of 3 out of key order",
+			locking:  redeemScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signatures(t, tx, redeemScript, keys[2], keys[0])
+			},
+			err: script.ErrScriptFailed,
+		},
+		{
+			name:     "bare 2 of 3 with a duplicated signature",
+			locking:  redeemScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				signature := signInput(t, keys[1], tx, redeemScript)
+
+				return script.AddData(script.AddData(nil, signature), signature)
+			},
+			err: script.ErrScriptFailed,
+		},
+		{
+			name:     "bare 2 of 3 with an outsider signature",
+			locking:  redeemScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signatures(t, tx, redeemScript, keys[0], outsider)
+			},
+			err: script.ErrScriptFailed,
+		},
+		{
+			name:     "bare 2 of 3 with one signature",
+			locking:  redeemScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signatures(t, tx, redeemScript, keys[1])
+			},
+			err: script.ErrStackUnderflow,
+		},
+		{
+			name:     "partially signed out of key order",
+			locking:  redeemScript,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signMultiSig(t, tx, redeemScript, keys[2], keys[0])
+			},
+		},
+		{
+			name:     "pay to script hash",
+			locking:  payToScriptHash,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.AddData(signatures(t, tx, redeemScript, keys[1], keys[2]), redeemScript)
+			},
+		},
+		{
+			name:     "pay to script hash partially signed",
+			locking:  payToScriptHash,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signMultiSig(t, tx, payToScriptHash, keys[1], keys[0])
+			},
+		},
+		{
+			name:     "pay to script hash with another redeem script",
+			locking:  payToScriptHash,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.AddData(signatures(t, tx, otherRedeemScript, keys[0]), otherRedeemScript)
+			},
+			err: script.ErrScriptFailed,
+		},
+		{
+			name:     "pay to script hash without a redeem script",
+			locking:  payToScriptHash,
+			sequence: SequenceFinal,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return signatures(t, tx, redeemScript, keys[0], keys[1])
+			},
+			err: script.ErrScriptFailed,
+		},
+	})
+}
+
+func TestLockTimeScripts(t *testing.T) {
+	alice := newTestKey(t)
+
+	lockedUntil := func(n int, op byte) []byte {
+		locking := script.AddOp(script.AddOp(script.AddInt(nil, n), op), script.OP_DROP)
+
+		return append(locking, alice.lockingScript...)
+	}
+
+	afterHeight := lockedUntil(100, script.OP_CHECKLOCKTIMEVERIFY)
+	afterTime := lockedUntil(LockTimeThreshold+100, script.OP_CHECKLOCKTIMEVERIFY)
+	afterBlocks := lockedUntil(10, script.OP_CHECKSEQUENCEVERIFY)
+	afterSeconds := lockedUntil(SequenceLockTimeType|10, script.OP_CHECKSEQUENCEVERIFY)
+	disabled := lockedUntil(SequenceLockDisabled, script.OP_CHECKSEQUENCEVERIFY)
+
+	tests := []struct {
+		name     string
+		locking  []byte
+		lockTime int
+		sequence uint32
+		err      error
+	}{
+		{"height lock met", afterHeight, 100, 0, nil},
+		{"height lock exceeded", afterHeight, 150, SequenceFinal - 1, nil},
+		{"height lock unmet", afterHeight, 99, 0, script.ErrLockTime},
+		{"height lock with a final sequence", afterHeight, 100, SequenceFinal, script.ErrLockTime},
+		{"height lock against a time lock", afterHeight, LockTimeThreshold + 100, 0, script.ErrLockTime},
+		{"time lock met", afterTime, LockTimeThreshold + 100, 0, nil},
+		{"time lock unmet", afterTime, LockTimeThreshold + 99, 0, script.ErrLockTime},
+		{"time lock against a height lock", afterTime, 100, 0, script.ErrLockTime},
+		{"block sequence met", afterBlocks, 0, 10, nil},
+		{"block sequence unmet", afterBlocks, 0, 9, script.ErrLockTime},
+		{"block sequence with a final sequence", afterBlocks, 0, SequenceFinal, script.ErrLockTime},
+		{"block sequence against a time sequence", afterBlocks, 0, SequenceLockTimeType | 10, script.ErrLockTime},
+		{"time sequence met", afterSeconds, 0, SequenceLockTimeType | 10, nil},
+		{"time sequence unmet", afterSeconds, 0, SequenceLockTimeType | 9, script.ErrLockTime},
+		{"disabled sequence check", disabled, 0, SequenceFinal, nil},
+	}
+
+	scriptTests := make([]scriptTest, len(tests))
+
+	for i, test := range tests {
+		scriptTests[i] = scriptTest{
+			name:     test.name,
+			locking:  test.locking,
+			lockTime: test.lockTime,
+			sequence: test.sequence,
+			unlock: func(t *testing.T, tx *Transaction) []byte {
+				return script.SignatureScript(signInput(t, alice, tx, test.locking), publicKeyBytes(alice.privKey))
+			},
+			err: test.err,
+		}
+	}
+
+	runScriptTests(t, scriptTests)
+}
+
+func TestIsFinal(t *testing.T) {
+	tests := []struct {
+		name     string
+		lockTime int
+		sequence uint32
+		height   int
+		time     int64
+		final    bool
+	}{
+		{"no lock time", 0, 0, 1, 0, true},
+		{"height reached", 100, 0, 100, 0, true},
+		{"height not reached", 101, 0, 100, 0, false},
+		{"height not reached with a final sequence", 101, SequenceFinal, 100, 0, true},
+		{"time reached", LockTimeThreshold + 10, 0, 1, LockTimeThreshold + 10, true},
+		{"time not reached", LockTimeThreshold + 10, 0, 1_000_000, LockTimeThreshold + 9, false},
+		{"time not reached with a final sequence", LockTimeThreshold + 10, SequenceFinal, 1, LockTimeThreshold, true},
+	}
+
+	for _, test := range tests {
+		tx := &Transaction{nil, []TxInput{{[]byte{1}, 0, nil, test.sequence}}, nil, test.lockTime}
+
+		if final := tx.IsFinal(test.height, test.time); final != test.final {
+			t.Errorf("%s: IsFinal is %t, want %t", test.name, final, test.final)
+		}
+	}
+}
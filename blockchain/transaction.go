@@ -6,19 +6,28 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
+	"github.com/e-aleixandre/go-blockchain/script"
 	"github.com/e-aleixandre/go-blockchain/wallet"
 	"log"
+	"math"
 	"math/big"
 	"strings"
 )
 
+const (
+	extraNonceLength    = 8
+	signatureHalfLength = 32
+)
+
 type Transaction struct {
-	ID      []byte
-	Inputs  []TxInput
-	Outputs []TxOutput
+	ID       []byte
+	Inputs   []TxInput
+	Outputs  []TxOutput
+	LockTime int
 }
 
 func (tx *Transaction) Serialize() []byte {
@@ -35,203 +44,364 @@ func (tx *Transaction) Serialize() []byte {
 
 }
 
-func (tx *Transaction) Hash() []byte {
-	var hash [32]byte
+func DeserializeTransaction(data []byte) (Transaction, error) {
+	var tx Transaction
+
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+	err := decoder.Decode(&tx)
 
-	txCopy := *tx
-	txCopy.ID = []byte{}
+	return tx, err
+}
 
-	hash = sha256.Sum256(txCopy.Serialize())
+func (tx *Transaction) Hash() []byte {
+	hash := sha256.Sum256(tx.canonicalBytes())
 
 	return hash[:]
 }
 
+func (tx *Transaction) Size() int {
+	return len(tx.canonicalBytes())
+}
+
 func (tx *Transaction) SetId() {
-	var encoded bytes.Buffer
-	var hash [32]byte
+	tx.ID = tx.Hash()
+}
+
+func (tx *Transaction) canonicalBytes() []byte {
+	var data []byte
+
+	data = binary.AppendUvarint(data, uint64(len(tx.Inputs)))
+
+	for _, in := range tx.Inputs {
+		data = appendBytes(data, in.ID)
+		data = binary.AppendVarint(data, int64(in.Out))
+		data = appendBytes(data, in.Script)
+		data = binary.AppendUvarint(data, uint64(in.Sequence))
+	}
+
+	data = binary.AppendUvarint(data, uint64(len(tx.Outputs)))
+
+	for _, out := range tx.Outputs {
+		data = binary.AppendVarint(data, int64(out.Value))
+		data = appendBytes(data, out.Script)
+	}
+
+	return binary.AppendVarint(data, int64(tx.LockTime))
+}
 
-	encode := gob.NewEncoder(&encoded)
-	err := encode.Encode(tx)
+func appendBytes(data, value []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(value)))
+
+	return append(data, value...)
+}
+
+func NewTransaction(wallets *wallet.Wallets, from, to string, amount, fee, lockTime int, passphrase string, chain *Blockchain, pool *Mempool) (*Transaction, error) {
+	if err := wallets.Unlock(passphrase); err != nil {
+		return nil, err
+	}
+
+	w, err := wallets.GetWallet(from)
 
 	if err != nil {
-		log.Panic(err)
+		return nil, err
+	}
+
+	lockingScript := script.PayToPubKeyHash(wallet.PublicKeyHash(w.PublicKey))
+	tx, err := NewUnsignedTransaction(lockingScript, to, amount, fee, lockTime, chain, pool)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chain.SignTransaction(tx, *w.PrivateKey); err != nil {
+		return nil, err
 	}
 
-	hash = sha256.Sum256(encoded.Bytes())
-	tx.ID = hash[:]
+	tx.ID = tx.Hash()
+
+	return tx, nil
 }
 
-func NewTransaction(from, to string, amount int, chain *Blockchain) *Transaction {
+func NewUnsignedTransaction(from []byte, to string, amount, fee, lockTime int, chain *Blockchain, pool *Mempool) (*Transaction, error) {
 	var inputs []TxInput
 	var outputs []TxOutput
 
-	wallets, err := wallet.CreateWallets()
+	if amount <= 0 || fee < 0 {
+		return nil, fmt.Errorf("invalid amount %d or fee %d", amount, fee)
+	}
 
-	if err != nil {
-		log.Panic(err)
+	if lockTime < 0 || lockTime > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid lock time %d", lockTime)
 	}
 
-	w := wallets.GetWallet(from)
-	pubKeyhash := wallet.PublicKeyHash(w.PublicKey)
+	sequence := uint32(SequenceFinal)
+
+	if lockTime > 0 {
+		sequence = SequenceLockDisabled
+	}
 
-	acc, validOutputs := chain.FindSpendableOutputs(pubKeyhash, amount)
+	acc, validOutputs, err := chain.FindSpendableOutputs(from, amount+fee, pool)
 
-	if acc < amount {
-		log.Panic("Error: not enough balance")
+	if err != nil {
+		return nil, err
+	}
+
+	if acc < amount+fee {
+		return nil, ErrInsufficientFunds
 	}
 
 	for txid, outs := range validOutputs {
 		txID, err := hex.DecodeString(txid)
 
 		if err != nil {
-			log.Panic(err)
+			return nil, err
 		}
 
 		for _, out := range outs {
-			input := TxInput{txID, out, nil, w.PublicKey}
+			input := TxInput{txID, out, nil, sequence}
 			inputs = append(inputs, input)
 		}
 	}
-	outputs = append(outputs, *NewTxOutput(amount, to))
 
-	if acc > amount {
-		outputs = append(outputs, TxOutput{acc - amount, pubKeyhash})
+	output, err := NewTxOutput(chain.Params, amount, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outputs = append(outputs, *output)
+
+	if acc > amount+fee {
+		outputs = append(outputs, TxOutput{acc - amount - fee, from})
 	}
 
-	tx := Transaction{nil, inputs, outputs}
+	tx := Transaction{nil, inputs, outputs, lockTime}
 	tx.ID = tx.Hash()
-	chain.SignTransaction(&tx, *w.PrivateKey)
 
-	return &tx
+	return &tx, nil
 }
 
-func CoinbaseTx(to, data string) *Transaction {
+func CoinbaseTx(params *Params, to, data string, height, fees int) (*Transaction, error) {
 	if data == "" {
 		data = fmt.Sprintf("Coins to %s", to)
 	}
 
-	txin := TxInput{[]byte{}, -1, nil, []byte(data)}
-	txout := NewTxOutput(100, to)
+	extraNonce := make([]byte, extraNonceLength)
+
+	if _, err := rand.Read(extraNonce); err != nil {
+		return nil, err
+	}
+
+	coinbaseScript := binary.AppendUvarint(nil, uint64(height))
+	coinbaseScript = append(coinbaseScript, extraNonce...)
+	coinbaseScript = append(coinbaseScript, data...)
+
+	txin := TxInput{[]byte{}, -1, coinbaseScript, SequenceFinal}
+	txout, err := NewTxOutput(params, params.Subsidy(height)+fees, to)
 
-	tx := Transaction{[]byte{}, []TxInput{txin}, []TxOutput{*txout}}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := Transaction{[]byte{}, []TxInput{txin}, []TxOutput{*txout}, 0}
 	tx.SetId()
 
-	return &tx
+	return &tx, nil
 }
 
 func (tx *Transaction) IsCoinbase() bool {
 	return len(tx.Inputs) == 1 && len(tx.Inputs[0].ID) == 0 && tx.Inputs[0].Out == -1
 }
 
+func (tx *Transaction) CoinbaseHeight() (int, bool) {
+	if !tx.IsCoinbase() {
+		return 0, false
+	}
+
+	height, n := binary.Uvarint(tx.Inputs[0].Script)
+
+	if n <= 0 || len(tx.Inputs[0].Script) < n+extraNonceLength {
+		return 0, false
+	}
+
+	return int(height), true
+}
+
 func (tx *Transaction) TrimmedCopy() Transaction {
 	var inputs []TxInput
 	var outputs []TxOutput
 
 	for _, in := range tx.Inputs {
-		inputs = append(inputs, TxInput{in.ID, in.Out, nil, nil})
+		inputs = append(inputs, TxInput{in.ID, in.Out, nil, in.Sequence})
 	}
 
 	for _, out := range tx.Outputs {
-		outputs = append(outputs, TxOutput{out.Value, out.PubKeyHash})
+		outputs = append(outputs, TxOutput{out.Value, out.Script})
 	}
 
-	txCopy := Transaction{tx.ID, inputs, outputs}
+	txCopy := Transaction{tx.ID, inputs, outputs, tx.LockTime}
 
 	return txCopy
 }
 
-func (tx *Transaction) Sign(privKey ecdsa.PrivateKey, prevTXs map[string]Transaction) {
-	if tx.IsCoinbase() {
-		return
+func prevOutput(prevTxs map[string]Transaction, in TxInput) (TxOutput, error) {
+	prevTx, ok := prevTxs[hex.EncodeToString(in.ID)]
+
+	if !ok || prevTx.ID == nil {
+		return TxOutput{}, ErrTxNotFound
 	}
 
-	for _, in := range tx.Inputs {
-		if prevTXs[hex.EncodeToString(in.ID)].ID == nil {
-			log.Panic("Error: Previous transaction does not exist")
-		}
+	if in.Out < 0 || in.Out >= len(prevTx.Outputs) {
+		return TxOutput{}, fmt.Errorf("transaction %x has no output %d", in.ID, in.Out)
+	}
 
-		txCopy := tx.TrimmedCopy()
+	return prevTx.Outputs[in.Out], nil
+}
+
+func (tx *Transaction) signatureHash(inIdx int, lockingScript []byte) []byte {
+	txCopy := tx.TrimmedCopy()
+	txCopy.Inputs[inIdx].Script = lockingScript
+
+	return txCopy.Hash()
+}
+
+func (tx *Transaction) Sign(privKey ecdsa.PrivateKey, prevTXs map[string]Transaction) error {
+	if tx.IsCoinbase() {
+		return nil
+	}
 
-		for inId, in := range txCopy.Inputs {
-			prevTx := prevTXs[hex.EncodeToString(in.ID)]
-			txCopy.Inputs[inId].Signature = nil
-			txCopy.Inputs[inId].PubKey = prevTx.Outputs[in.Out].PubKeyHash
-			txCopy.ID = txCopy.Hash()
-			txCopy.Inputs[inId].PubKey = nil
+	pubKey := publicKeyBytes(privKey)
 
-			r, s, err := ecdsa.Sign(rand.Reader, &privKey, txCopy.ID)
+	for inId, in := range tx.Inputs {
+		prevOut, err := prevOutput(prevTXs, in)
 
-			if err != nil {
-				log.Panic(err)
-			}
+		if err != nil {
+			return err
+		}
 
-			signature := append(r.Bytes(), s.Bytes()...)
+		signature, err := signHash(privKey, tx.signatureHash(inId, prevOut.Script))
 
-			tx.Inputs[inId].Signature = signature
+		if err != nil {
+			return err
 		}
 
+		tx.Inputs[inId].Script = script.SignatureScript(signature, pubKey)
 	}
+
+	return nil
+}
+
+func signHash(privKey ecdsa.PrivateKey, hash []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, &privKey, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signature := make([]byte, 2*signatureHalfLength)
+	r.FillBytes(signature[:signatureHalfLength])
+	s.FillBytes(signature[signatureHalfLength:])
+
+	return signature, nil
+}
+
+func publicKeyBytes(privKey ecdsa.PrivateKey) []byte {
+	return append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
 }
 
-func (tx *Transaction) Verify(prevTxs map[string]Transaction) bool {
+func (tx *Transaction) Verify(prevOuts []TxOutput) error {
 	if tx.IsCoinbase() {
-		return true
+		return nil
 	}
 
-	for _, in := range tx.Inputs {
-		if prevTxs[hex.EncodeToString(in.ID)].ID == nil {
-			log.Panic("Error: previous transaction does not exist")
+	if len(prevOuts) != len(tx.Inputs) {
+		return fmt.Errorf("transaction %x has %d inputs but %d spent outputs were given", tx.ID, len(tx.Inputs), len(prevOuts))
+	}
+
+	for inId, in := range tx.Inputs {
+		checker := &inputChecker{tx, inId}
+
+		if err := script.Execute(in.Script, prevOuts[inId].Script, checker); err != nil {
+			return fmt.Errorf("%w: input %d: %w", ErrInvalidSignature, inId, err)
 		}
 	}
 
-	txCopy := tx.TrimmedCopy()
+	return nil
+}
+
+type inputChecker struct {
+	tx    *Transaction
+	inIdx int
+}
+
+func (c *inputChecker) CheckLockTime(lockTime int64) bool {
+	return c.tx.checkLockTime(c.inIdx, lockTime)
+}
+
+func (c *inputChecker) CheckSequence(sequence int64) bool {
+	return c.tx.checkSequence(c.inIdx, sequence)
+}
+
+func (c *inputChecker) CheckSignature(signature, pubKey, scriptCode []byte) bool {
+	key, ok := parsePublicKey(pubKey)
+
+	if !ok || len(signature) != 2*signatureHalfLength {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:signatureHalfLength])
+	s := new(big.Int).SetBytes(signature[signatureHalfLength:])
+
+	return ecdsa.Verify(key, c.tx.signatureHash(c.inIdx, scriptCode), r, s)
+}
+
+func parsePublicKey(data []byte) (*ecdsa.PublicKey, bool) {
 	curve := elliptic.P256()
+	size := (curve.Params().BitSize + 7) / 8
 
-	for inId, in := range tx.Inputs {
-		prevTx := prevTxs[hex.EncodeToString(in.ID)]
-		txCopy.Inputs[inId].Signature = nil
-		txCopy.Inputs[inId].PubKey = prevTx.Outputs[in.Out].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Inputs[inId].PubKey = nil
-
-		r := big.Int{}
-		s := big.Int{}
-		signatureLength := len(in.Signature)
-		r.SetBytes(in.Signature[:signatureLength/2])
-		s.SetBytes(in.Signature[signatureLength/2:])
-
-		x := big.Int{}
-		y := big.Int{}
-		keyLength := len(in.PubKey)
-		x.SetBytes(in.PubKey[:keyLength/2])
-		y.SetBytes(in.PubKey[keyLength/2:])
-
-		rawPubKey := ecdsa.PublicKey{curve, &x, &y}
-
-		if !ecdsa.Verify(&rawPubKey, txCopy.ID, &r, &s) {
-			return false
+	for split := len(data) - size; split <= size; split++ {
+		if split <= 0 || split >= len(data) {
+			continue
+		}
+
+		x := new(big.Int).SetBytes(data[:split])
+		y := new(big.Int).SetBytes(data[split:])
+
+		if curve.IsOnCurve(x, y) {
+			return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, true
 		}
 	}
 
-	return true
+	return nil, false
 }
 
 func (tx *Transaction) String() string {
 	var lines []string
 
 	lines = append(lines, fmt.Sprintf("--- Transaction %x: ", tx.ID))
+	if tx.LockTime != 0 {
+		lines = append(lines, fmt.Sprintf("\tLock time: %d", tx.LockTime))
+	}
+
 	for i, input := range tx.Inputs {
 		lines = append(lines, fmt.Sprintf("\tInput %d:", i))
 		lines = append(lines, fmt.Sprintf("\t\tTxID: %x", input.ID))
 		lines = append(lines, fmt.Sprintf("\t\tOut: %d", input.Out))
-		lines = append(lines, fmt.Sprintf("\t\tSignature: %x", input.Signature))
-		lines = append(lines, fmt.Sprintf("\t\tPubKey: %x", input.PubKey))
+		if tx.IsCoinbase() {
+			lines = append(lines, fmt.Sprintf("\t\tCoinbase: %x", input.Script))
+		} else {
+			lines = append(lines, fmt.Sprintf("\t\tScript: %s", script.Disassemble(input.Script)))
+		}
+
+		if input.Sequence != SequenceFinal {
+			lines = append(lines, fmt.Sprintf("\t\tSequence: %#x", input.Sequence))
+		}
 	}
 
 	for i, output := range tx.Outputs {
 		lines = append(lines, fmt.Sprintf("\tOutput %d:", i))
 		lines = append(lines, fmt.Sprintf("\t\tValue: %d", output.Value))
-		lines = append(lines, fmt.Sprintf("\t\tScript: %x", output.PubKeyHash))
+		lines = append(lines, fmt.Sprintf("\t\tScript: %s", script.Disassemble(output.Script)))
 	}
 
 	return strings.Join(lines, "\n")
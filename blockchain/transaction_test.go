@@ -0,0 +1,94 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestCoinbaseIDsAreUnique(t *testing.T) {
+	alice := newTestKey(t)
+
+	first, err := CoinbaseTx(&RegTestParams, alice.address, "", 1, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := CoinbaseTx(&RegTestParams, alice.address, "", 1, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(first.ID) == hex.EncodeToString(second.ID) {
+		t.Fatal("two coinbases to the same address at the same height share an ID")
+	}
+
+	if height, ok := first.CoinbaseHeight(); !ok || height != 1 {
+		t.Fatalf("coinbase commits to height %d, want 1", height)
+	}
+}
+
+func TestMiningToOneAddressKeepsEveryCoinbase(t *testing.T) {
+	alice := newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, 20)
+	seen := map[string]bool{}
+
+	for _, block := range mined {
+		seen[hex.EncodeToString(block.Transactions[0].ID)] = true
+	}
+
+	if len(seen) != len(mined) {
+		t.Fatalf("%d blocks produced only %d distinct coinbase IDs", len(mined), len(seen))
+	}
+
+	count, err := chain.CountUTXO()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := len(mined) + 1; count != want {
+		t.Fatalf("UTXO set has %d outputs, want %d", count, want)
+	}
+
+	if got, want := balance(t, chain, alice), (len(mined)+1)*chain.Params.Subsidy(0); got != want {
+		t.Fatalf("balance is %d, want %d", got, want)
+	}
+}
+
+func TestValidateBlockRejectsWrongCoinbaseHeight(t *testing.T) {
+	alice := newTestKey(t)
+	chain := newTestChain(t, alice)
+	tip, err := chain.GetBlock(chain.Tip())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbase, err := CoinbaseTx(chain.Params, alice.address, "", tip.Height+2, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := CreateBlock(context.Background(), chain.Miner, []*Transaction{coinbase}, BlockHeader{
+		Height:    tip.Height + 1,
+		Timestamp: tip.Timestamp + 1,
+		PrevHash:  tip.Hash,
+		Bits:      tip.Bits,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var invalid *ValidationError
+
+	if err := chain.ValidateBlock(block); !errors.As(err, &invalid) {
+		t.Fatalf("block with a coinbase for the wrong height was not rejected: %v", err)
+	}
+}
@@ -2,41 +2,97 @@ package blockchain
 
 import (
 	"bytes"
+	"encoding/gob"
+	"github.com/e-aleixandre/go-blockchain/script"
 	"github.com/e-aleixandre/go-blockchain/wallet"
+	"log"
 )
 
 type TxOutput struct {
-	Value      int
-	PubKeyHash []byte
+	Value  int
+	Script []byte
 }
 
 type TxInput struct {
-	ID        []byte
-	Out       int
-	Signature []byte
-	PubKey    []byte
+	ID       []byte
+	Out      int
+	Script   []byte
+	Sequence uint32
 }
 
-func NewTxOutput(value int, address string) *TxOutput {
+func NewTxOutput(params *Params, value int, address string) (*TxOutput, error) {
 	txo := TxOutput{value, nil}
-	txo.Lock([]byte(address))
 
-	return &txo
+	if err := txo.Lock(params, []byte(address)); err != nil {
+		return nil, err
+	}
+
+	return &txo, nil
+}
+
+func (out *TxOutput) Serialize() []byte {
+	var buffer bytes.Buffer
+
+	encoder := gob.NewEncoder(&buffer)
+
+	if err := encoder.Encode(out); err != nil {
+		log.Panic(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func DeserializeOutput(data []byte) (TxOutput, error) {
+	var out TxOutput
+
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+	err := decoder.Decode(&out)
+
+	return out, err
 }
 
-func (in *TxInput) UsesKey(pubKeyHash []byte) bool {
-	lockingHash := wallet.PublicKeyHash(in.PubKey)
+func LockingScript(params *Params, address string) ([]byte, error) {
+	if pubKeyHash, err := wallet.AddressToPubKeyHash(address, params.AddressVersion); err == nil {
+		return script.PayToPubKeyHash(pubKeyHash), nil
+	}
 
-	return bytes.Compare(lockingHash, pubKeyHash) == 0
+	scriptHash, err := wallet.AddressToPubKeyHash(address, params.ScriptVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return script.PayToScriptHash(scriptHash), nil
+}
+
+func ExtractAddress(params *Params, lockingScript []byte) (string, bool) {
+	if pubKeyHash, ok := script.ExtractPubKeyHash(lockingScript); ok {
+		return string(wallet.EncodeAddress(pubKeyHash, params.AddressVersion)), true
+	}
+
+	if scriptHash, ok := script.ExtractScriptHash(lockingScript); ok {
+		return string(wallet.EncodeAddress(scriptHash, params.ScriptVersion)), true
+	}
+
+	return "", false
 }
 
-func (out *TxOutput) Lock(address []byte) {
-	pubKeyHash := wallet.Base58Decode(address)
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
+func (out *TxOutput) Lock(params *Params, address []byte) error {
+	lockingScript, err := LockingScript(params, string(address))
+
+	if err != nil {
+		return err
+	}
+
+	out.Script = lockingScript
+
+	return nil
+}
 
-	out.PubKeyHash = pubKeyHash
+func (out *TxOutput) IsLockedWith(lockingScript []byte) bool {
+	return bytes.Equal(out.Script, lockingScript)
 }
 
-func (out *TxOutput) IsLockedWithKey(pubKeyHash []byte) bool {
-	return bytes.Compare(out.PubKeyHash, pubKeyHash) == 0
+func (out *TxOutput) IsUnspendable() bool {
+	return script.IsUnspendable(out.Script)
 }
@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/dgraph-io/badger/v4"
+	"log"
+)
+
+const (
+	txIndexPrefix = "tx-"
+	txIndexKey    = "txindex"
+)
+
+type TxLocation struct {
+	BlockHash []byte
+	Position  int
+}
+
+func (location *TxLocation) Serialize() []byte {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(location); err != nil {
+		log.Panic(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func DeserializeTxLocation(data []byte) (TxLocation, error) {
+	var location TxLocation
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&location)
+
+	return location, err
+}
+
+func txIndexEntryKey(ID []byte) []byte {
+	return append([]byte(txIndexPrefix), ID...)
+}
+
+func hasKey(txn *badger.Txn, key string) (bool, error) {
+	_, err := txn.Get([]byte(key))
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (chain *Blockchain) EnableTxIndex() error {
+	if chain.TxIndex {
+		return nil
+	}
+
+	if err := chain.ReindexTransactions(); err != nil {
+		return err
+	}
+
+	err := chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(txIndexKey), []byte{1})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	chain.TxIndex = true
+
+	return nil
+}
+
+func (chain *Blockchain) ReindexTransactions() error {
+	if err := chain.deleteByPrefix([]byte(txIndexPrefix)); err != nil {
+		return err
+	}
+
+	batch := chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	iter := chain.Iterator()
+
+	for {
+		block, err := iter.Next()
+
+		if err != nil {
+			return err
+		}
+
+		for i, tx := range block.Transactions {
+			location := TxLocation{block.Hash, i}
+
+			if err := batch.Set(txIndexEntryKey(tx.ID), location.Serialize()); err != nil {
+				return err
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+	}
+
+	return batch.Flush()
+}
+
+func indexTransactions(txn *badger.Txn, block *Block) error {
+	for i, tx := range block.Transactions {
+		location := TxLocation{block.Hash, i}
+
+		if err := txn.Set(txIndexEntryKey(tx.ID), location.Serialize()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unindexTransactions(txn *badger.Txn, block *Block) error {
+	for _, tx := range block.Transactions {
+		if err := txn.Delete(txIndexEntryKey(tx.ID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (chain *Blockchain) LocateTransaction(ID []byte) (*Block, int, error) {
+	if !chain.TxIndex {
+		return chain.scanTransaction(ID)
+	}
+
+	var location TxLocation
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(txIndexEntryKey(ID))
+
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			location, err = DeserializeTxLocation(val)
+
+			return err
+		})
+	})
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, 0, ErrTxNotFound
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	block, err := chain.GetBlock(location.BlockHash)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if location.Position >= len(block.Transactions) || !bytes.Equal(block.Transactions[location.Position].ID, ID) {
+		return nil, 0, ErrTxNotFound
+	}
+
+	return block, location.Position, nil
+}
+
+func (chain *Blockchain) scanTransaction(ID []byte) (*Block, int, error) {
+	iter := chain.Iterator()
+
+	for {
+		block, err := iter.Next()
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i, tx := range block.Transactions {
+			if bytes.Equal(tx.ID, ID) {
+				return block, i, nil
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			return nil, 0, ErrTxNotFound
+		}
+	}
+}
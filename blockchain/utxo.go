@@ -0,0 +1,442 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"github.com/dgraph-io/badger/v4"
+	"log"
+)
+
+const (
+	utxoPrefix     = "utxo-"
+	utxoReindexKey = "utxoreindex"
+)
+
+type UTXO struct {
+	TxOutput
+	Height   int
+	Time     int64
+	Coinbase bool
+}
+
+func (utxo *UTXO) Serialize() []byte {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(utxo); err != nil {
+		log.Panic(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func DeserializeUTXO(data []byte) (UTXO, error) {
+	var utxo UTXO
+
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+	err := decoder.Decode(&utxo)
+
+	return utxo, err
+}
+
+func (utxo *UTXO) IsMature(params *Params, height int) bool {
+	return !utxo.Coinbase || height-utxo.Height >= params.CoinbaseMaturity
+}
+
+func utxoKey(txID []byte, outIdx int) []byte {
+	key := make([]byte, 0, len(utxoPrefix)+len(txID)+4)
+	key = append(key, utxoPrefix...)
+	key = append(key, txID...)
+
+	return binary.BigEndian.AppendUint32(key, uint32(outIdx))
+}
+
+func parseUTXOKey(key []byte) ([]byte, int) {
+	key = key[len(utxoPrefix):]
+	txID := key[:len(key)-4]
+	outIdx := int(binary.BigEndian.Uint32(key[len(key)-4:]))
+
+	return txID, outIdx
+}
+
+func updateUTXO(txn *badger.Txn, block *Block) error {
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Inputs {
+				if err := txn.Delete(utxoKey(in.ID, in.Out)); err != nil {
+					return err
+				}
+			}
+		}
+
+		for outIdx, out := range tx.Outputs {
+			if out.IsUnspendable() {
+				continue
+			}
+
+			utxo := UTXO{out, block.Height, block.Timestamp, tx.IsCoinbase()}
+
+			if err := txn.Set(utxoKey(tx.ID, outIdx), utxo.Serialize()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (chain *Blockchain) GetUTXO(txID []byte, outIdx int) (UTXO, bool, error) {
+	var out UTXO
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(utxoKey(txID, outIdx))
+
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			out, err = DeserializeUTXO(val)
+
+			return err
+		})
+	})
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return UTXO{}, false, nil
+	}
+
+	if err != nil {
+		return UTXO{}, false, err
+	}
+
+	return out, true, nil
+}
+
+func (chain *Blockchain) forEachUTXO(fn func(txID []byte, outIdx int, out UTXO) bool) error {
+	prefix := []byte(utxoPrefix)
+
+	return chain.Database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			txID, outIdx := parseUTXOKey(item.KeyCopy(nil))
+
+			var out UTXO
+			err := item.Value(func(val []byte) error {
+				var err error
+				out, err = DeserializeUTXO(val)
+
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			if !fn(txID, outIdx, out) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
+func (chain *Blockchain) FindUTXO(lockingScript []byte) ([]UTXO, error) {
+	var UTXOs []UTXO
+
+	err := chain.forEachUTXO(func(_ []byte, _ int, out UTXO) bool {
+		if out.IsLockedWith(lockingScript) {
+			UTXOs = append(UTXOs, out)
+		}
+
+		return true
+	})
+
+	return UTXOs, err
+}
+
+type UnspentOutput struct {
+	TxID  []byte
+	Index int
+	UTXO
+}
+
+func (chain *Blockchain) FindUnspentOutputs(lockingScript []byte) ([]UnspentOutput, error) {
+	var unspent []UnspentOutput
+
+	err := chain.forEachUTXO(func(txID []byte, outIdx int, out UTXO) bool {
+		if out.IsLockedWith(lockingScript) {
+			unspent = append(unspent, UnspentOutput{txID, outIdx, out})
+		}
+
+		return true
+	})
+
+	return unspent, err
+}
+
+func (chain *Blockchain) GetBalance(lockingScript []byte) (int, int, error) {
+	height, err := chain.GetBestHeight()
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	UTXOs, err := chain.FindUTXO(lockingScript)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	spendable, immature := 0, 0
+
+	for _, out := range UTXOs {
+		if out.IsMature(chain.Params, height+1) {
+			spendable += out.Value
+		} else {
+			immature += out.Value
+		}
+	}
+
+	return spendable, immature, nil
+}
+
+func (chain *Blockchain) FindSpendableOutputs(lockingScript []byte, amount int, pool *Mempool) (int, map[string][]int, error) {
+	unspentOuts := make(map[string][]int)
+	accumulated := 0
+
+	height, err := chain.GetBestHeight()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = chain.forEachUTXO(func(txID []byte, outIdx int, out UTXO) bool {
+		if !out.IsLockedWith(lockingScript) || !out.IsMature(chain.Params, height+1) {
+			return true
+		}
+
+		if pool != nil && pool.IsSpent(txID, outIdx) {
+			return true
+		}
+
+		ID := hex.EncodeToString(txID)
+		accumulated += out.Value
+		unspentOuts[ID] = append(unspentOuts[ID], outIdx)
+
+		return accumulated < amount
+	})
+
+	return accumulated, unspentOuts, err
+}
+
+func (chain *Blockchain) CountUTXO() (int, error) {
+	count := 0
+
+	err := chain.forEachUTXO(func(_ []byte, _ int, _ UTXO) bool {
+		count++
+
+		return true
+	})
+
+	return count, err
+}
+
+func (chain *Blockchain) Issued() (int, error) {
+	issued := 0
+
+	hashes, err := chain.mainChainHashes()
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, hash := range hashes {
+		block, err := chain.GetBlock(hash)
+
+		if err != nil {
+			return 0, err
+		}
+
+		undo, err := chain.getUndo(hash)
+
+		if err != nil {
+			return 0, err
+		}
+
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Outputs {
+				issued += out.Value
+			}
+		}
+
+		for _, spent := range undo {
+			issued -= spent.Output.Value
+		}
+	}
+
+	return issued, nil
+}
+
+func (chain *Blockchain) Circulating() (int, error) {
+	circulating := 0
+
+	err := chain.forEachUTXO(func(_ []byte, _ int, out UTXO) bool {
+		circulating += out.Value
+
+		return true
+	})
+
+	return circulating, err
+}
+
+func (chain *Blockchain) ReindexUTXO() error {
+	UTXOs, err := chain.findAllUnspentOutputs()
+
+	if err != nil {
+		return err
+	}
+
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(utxoReindexKey), []byte{1})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := chain.deleteByPrefix([]byte(utxoPrefix)); err != nil {
+		return err
+	}
+
+	batch := chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	for txID, outs := range UTXOs {
+		ID, err := hex.DecodeString(txID)
+
+		if err != nil {
+			return err
+		}
+
+		for outIdx, out := range outs {
+			if err := batch.Set(utxoKey(ID, outIdx), out.Serialize()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := batch.Flush(); err != nil {
+		return err
+	}
+
+	return chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(utxoReindexKey))
+	})
+}
+
+func (chain *Blockchain) checkUTXOSet() error {
+	var interrupted bool
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		var err error
+
+		interrupted, err = hasKey(txn, utxoReindexKey)
+
+		return err
+	})
+
+	if err != nil || !interrupted {
+		return err
+	}
+
+	return chain.ReindexUTXO()
+}
+
+func (chain *Blockchain) findAllUnspentOutputs() (map[string]map[int]UTXO, error) {
+	UTXOs := make(map[string]map[int]UTXO)
+	spentTxOutputs := make(map[string]map[int]bool)
+
+	iter := chain.Iterator()
+
+	for {
+		block, err := iter.Next()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i := len(block.Transactions) - 1; i >= 0; i-- {
+			tx := block.Transactions[i]
+			txID := hex.EncodeToString(tx.ID)
+
+			for outIdx, out := range tx.Outputs {
+				if spentTxOutputs[txID][outIdx] || out.IsUnspendable() {
+					continue
+				}
+
+				if UTXOs[txID] == nil {
+					UTXOs[txID] = make(map[int]UTXO)
+				}
+
+				UTXOs[txID][outIdx] = UTXO{out, block.Height, block.Timestamp, tx.IsCoinbase()}
+			}
+
+			if !tx.IsCoinbase() {
+				for _, in := range tx.Inputs {
+					inTxID := hex.EncodeToString(in.ID)
+
+					if spentTxOutputs[inTxID] == nil {
+						spentTxOutputs[inTxID] = make(map[int]bool)
+					}
+
+					spentTxOutputs[inTxID][in.Out] = true
+				}
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+	}
+
+	return UTXOs, nil
+}
+
+func (chain *Blockchain) deleteByPrefix(prefix []byte) error {
+	var keys [][]byte
+
+	err := chain.Database.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	batch := chain.Database.NewWriteBatch()
+	defer batch.Cancel()
+
+	for _, key := range keys {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return batch.Flush()
+}
@@ -0,0 +1,95 @@
+package blockchain
+
+import (
+	"github.com/dgraph-io/badger/v4"
+	"testing"
+)
+
+func TestIssuedFollowsTheSubsidySchedule(t *testing.T) {
+	alice, bob := newTestKey(t), newTestKey(t)
+	chain := newTestChain(t, alice)
+	mined := mineBlocks(t, chain, alice, chain.Params.CoinbaseMaturity+1)
+	toBob := spend(t, alice, mined[0].Transactions[0], 0, bob, 30, 2)
+	tip := buildBlock(t, chain, mined[len(mined)-1], alice, toBob)
+	importBlock(t, chain, tip)
+
+	issued, err := chain.Issued()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	circulating, err := chain.Circulating()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := chain.Params.IssuedAt(tip.Height) - 2; issued != want {
+		t.Fatalf("issued %d, want the scheduled issuance minus the unclaimed fee, %d", issued, want)
+	}
+
+	if circulating != issued {
+		t.Fatalf("circulating %d differs from issued %d without burned outputs", circulating, issued)
+	}
+}
+
+func TestContinueBlockchainResumesInterruptedUTXOReindex(t *testing.T) {
+	alice := newTestKey(t)
+	dir := t.TempDir()
+	chain, err := InitBlockchain(dir, &RegTestParams, alice.address, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mineBlocks(t, chain, alice, 5)
+
+	want, err := chain.CountUTXO()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = chain.Database.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(utxoReindexKey), []byte{1})
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.deleteByPrefix([]byte(utxoPrefix)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.ShutdownDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err = ContinueBlockchain(dir, &RegTestParams)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { chain.ShutdownDB() })
+
+	if count, err := chain.CountUTXO(); err != nil || count != want {
+		t.Fatalf("UTXO set has %d outputs after resuming (%v), want %d", count, err, want)
+	}
+
+	err = chain.Database.View(func(txn *badger.Txn) error {
+		interrupted, err := hasKey(txn, utxoReindexKey)
+
+		if err == nil && interrupted {
+			t.Error("reindex marker was left behind")
+		}
+
+		return err
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,324 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+type ValidationError struct {
+	Hash   []byte
+	Height int
+	Err    error
+}
+
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("block %x at height %d is invalid: %s", err.Hash, err.Height, err.Err)
+}
+
+func (err *ValidationError) Unwrap() error {
+	return err.Err
+}
+
+func invalidBlock(block *Block, format string, args ...interface{}) error {
+	return &ValidationError{block.Hash, block.Height, fmt.Errorf(format, args...)}
+}
+
+func invalidTransaction(tx *Transaction, format string, args ...interface{}) error {
+	return fmt.Errorf("%w %x: %w", ErrInvalidTransaction, tx.ID, fmt.Errorf(format, args...))
+}
+
+type utxoView interface {
+	GetUTXO(txID []byte, outIdx int) (UTXO, bool, error)
+}
+
+type memoryView struct {
+	parent  utxoView
+	added   map[string]UTXO
+	removed map[string]bool
+}
+
+func outpoint(txID []byte, outIdx int) string {
+	return string(utxoKey(txID, outIdx))
+}
+
+func newMemoryView(parent utxoView) *memoryView {
+	return &memoryView{parent, make(map[string]UTXO), make(map[string]bool)}
+}
+
+func (view *memoryView) GetUTXO(txID []byte, outIdx int) (UTXO, bool, error) {
+	key := outpoint(txID, outIdx)
+
+	if view.removed[key] {
+		return UTXO{}, false, nil
+	}
+
+	if out, ok := view.added[key]; ok {
+		return out, true, nil
+	}
+
+	if view.parent == nil {
+		return UTXO{}, false, nil
+	}
+
+	return view.parent.GetUTXO(txID, outIdx)
+}
+
+func (view *memoryView) add(txID []byte, outIdx int, out UTXO) {
+	key := outpoint(txID, outIdx)
+	delete(view.removed, key)
+	view.added[key] = out
+}
+
+func (view *memoryView) remove(txID []byte, outIdx int) {
+	key := outpoint(txID, outIdx)
+	delete(view.added, key)
+	view.removed[key] = true
+}
+
+func (view *memoryView) apply(tx *Transaction, height int, blockTime int64) {
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Inputs {
+			view.remove(in.ID, in.Out)
+		}
+	}
+
+	for outIdx, out := range tx.Outputs {
+		if out.IsUnspendable() {
+			continue
+		}
+
+		view.add(tx.ID, outIdx, UTXO{out, height, blockTime, tx.IsCoinbase()})
+	}
+}
+
+func (chain *Blockchain) ValidateBlock(block *Block) error {
+	return chain.validateBlock(block, chain)
+}
+
+func (chain *Blockchain) validateHeader(block *Block) error {
+	hash := sha256.Sum256(block.BlockHeader.Serialize())
+
+	if !bytes.Equal(hash[:], block.Hash) {
+		return invalidBlock(block, "block hash does not match its header")
+	}
+
+	if len(block.PrevHash) == 0 {
+		if block.Height != 0 {
+			return invalidBlock(block, "block without previous hash is not at height 0")
+		}
+	} else {
+		prev, err := chain.GetBlock(block.PrevHash)
+
+		if errors.Is(err, ErrBlockNotFound) {
+			return invalidBlock(block, "%w", ErrOrphanBlock)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if prev.Height+1 != block.Height {
+			return invalidBlock(block, "block height does not follow its previous block")
+		}
+
+		median, err := chain.MedianTimePast(prev)
+
+		if err != nil {
+			return err
+		}
+
+		if block.Timestamp <= median {
+			return invalidBlock(block, "timestamp %d is not after the median time %d of the previous blocks", block.Timestamp, median)
+		}
+	}
+
+	if limit := chain.now().Add(chain.Params.MaxFutureTime).Unix(); block.Timestamp > limit {
+		return invalidBlock(block, "timestamp %d is more than %s in the future", block.Timestamp, chain.Params.MaxFutureTime)
+	}
+
+	bits, err := chain.ExpectedBits(block)
+
+	if err != nil {
+		return err
+	}
+
+	if !NewProof(block, bits).Validate() {
+		return invalidBlock(block, "proof of work is invalid")
+	}
+
+	if !bytes.Equal(block.MerkleRoot, block.HashTransactions()) {
+		return invalidBlock(block, "merkle root does not match the transactions")
+	}
+
+	return nil
+}
+
+func (chain *Blockchain) validateBlock(block *Block, view utxoView) error {
+	if err := chain.validateHeader(block); err != nil {
+		return err
+	}
+
+	if len(block.Transactions) == 0 || !block.Transactions[0].IsCoinbase() {
+		return invalidBlock(block, "first transaction is not a coinbase")
+	}
+
+	if height, ok := block.Transactions[0].CoinbaseHeight(); !ok || height != block.Height {
+		return invalidBlock(block, "coinbase does not commit to height %d", block.Height)
+	}
+
+	blockView := newMemoryView(view)
+	size, fees := 0, 0
+
+	for i, tx := range block.Transactions {
+		size += tx.Size()
+
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			return invalidBlock(block, "transaction %x has a wrong ID", tx.ID)
+		}
+
+		for outIdx := range tx.Outputs {
+			_, exists, err := blockView.GetUTXO(tx.ID, outIdx)
+
+			if err != nil {
+				return err
+			}
+
+			if exists {
+				return invalidBlock(block, "transaction %x overwrites an unspent output", tx.ID)
+			}
+		}
+
+		if !tx.IsFinal(block.Height, block.Timestamp) {
+			return invalidBlock(block, "transaction %x is locked until %d", tx.ID, tx.LockTime)
+		}
+
+		if i > 0 {
+			if tx.IsCoinbase() {
+				return invalidBlock(block, "transaction %x is an extra coinbase", tx.ID)
+			}
+
+			fee, err := chain.validateTransaction(tx, blockView, block.Height, block.Timestamp)
+
+			if errors.Is(err, ErrInvalidTransaction) {
+				return &ValidationError{block.Hash, block.Height, err}
+			}
+
+			if err != nil {
+				return err
+			}
+
+			fees += fee
+		}
+
+		blockView.apply(tx, block.Height, block.Timestamp)
+	}
+
+	if size > chain.Params.MaxBlockSize {
+		return invalidBlock(block, "block size %d exceeds the limit of %d", size, chain.Params.MaxBlockSize)
+	}
+
+	reward := 0
+
+	for _, out := range block.Transactions[0].Outputs {
+		if out.Value < 0 {
+			return invalidBlock(block, "coinbase has a negative output")
+		}
+
+		reward += out.Value
+	}
+
+	if allowed := chain.Params.Subsidy(block.Height) + fees; reward > allowed {
+		return invalidBlock(block, "coinbase pays %d but subsidy plus fees is only %d", reward, allowed)
+	}
+
+	return nil
+}
+
+func (chain *Blockchain) validateTransaction(tx *Transaction, view utxoView, height int, blockTime int64) (int, error) {
+	if len(tx.Inputs) == 0 {
+		return 0, invalidTransaction(tx, "it has no inputs")
+	}
+
+	var prevOuts []TxOutput
+
+	spent := make(map[string]bool)
+	inputs, outputs := 0, 0
+
+	for _, in := range tx.Inputs {
+		key := outpoint(in.ID, in.Out)
+
+		if spent[key] {
+			return 0, invalidTransaction(tx, "it spends %x:%d twice", in.ID, in.Out)
+		}
+
+		spent[key] = true
+		out, ok, err := view.GetUTXO(in.ID, in.Out)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if !ok {
+			return 0, invalidTransaction(tx, "it spends missing or spent output %x:%d", in.ID, in.Out)
+		}
+
+		if !out.IsMature(chain.Params, height) {
+			return 0, invalidTransaction(tx, "it spends %x:%d: %w", in.ID, in.Out, ErrImmatureCoinbase)
+		}
+
+		if !in.SequenceLockSatisfied(out, height, blockTime) {
+			return 0, invalidTransaction(tx, "it spends %x:%d: %w", in.ID, in.Out, ErrSequenceLocked)
+		}
+
+		inputs += out.Value
+		prevOuts = append(prevOuts, out.TxOutput)
+	}
+
+	for _, out := range tx.Outputs {
+		if out.Value < 0 {
+			return 0, invalidTransaction(tx, "it has a negative output")
+		}
+
+		outputs += out.Value
+	}
+
+	if inputs < outputs {
+		return 0, invalidTransaction(tx, "it spends %d but only has %d in inputs", outputs, inputs)
+	}
+
+	if err := tx.Verify(prevOuts); err != nil {
+		return 0, invalidTransaction(tx, "%w", err)
+	}
+
+	return inputs - outputs, nil
+}
+
+func (chain *Blockchain) ValidateChain() (*Block, error) {
+	hashes, err := chain.mainChainHashes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	view := newMemoryView(nil)
+
+	for i := len(hashes) - 1; i >= 0; i-- {
+		block, err := chain.GetBlock(hashes[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := chain.validateBlock(block, view); err != nil {
+			return block, err
+		}
+
+		for _, tx := range block.Transactions {
+			view.apply(tx, block.Height, block.Timestamp)
+		}
+	}
+
+	return nil, nil
+}
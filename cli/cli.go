@@ -1,222 +1,1452 @@
 package cli
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/merkle"
+	"github.com/e-aleixandre/go-blockchain/network"
+	"github.com/e-aleixandre/go-blockchain/rpc"
 	"github.com/e-aleixandre/go-blockchain/wallet"
-	"log"
+	"io"
 	"os"
-	"runtime"
+	"os/signal"
 	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitChainNotFound
+	exitChainExists
+	exitInsufficientFunds
+	exitUnknownWallet
+	exitInvalidSignature
+	exitInvalidAddress
+	exitInvalidChain
+	exitWrongNetwork
+	exitWalletLocked
+	exitWrongPassphrase
+)
+
+const defaultDataDir = "./tmp"
+
+var stdin = bufio.NewReader(os.Stdin)
+
 type CommandLine struct {
+	dataDir  string
+	params   *blockchain.Params
+	client   *rpc.Client
+	rpcToken string
+	txIndex  bool
+}
+
+func (cli *CommandLine) report(err error) int {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	}
+
+	return exitCode(err)
+}
+
+func (cli *CommandLine) printUsage() {
+	fmt.Println("Usage: [-datadir DIR] [-network main|test|regtest] [-rpcconnect HOST:PORT] [-rpctoken TOKEN] [-txindex] COMMAND")
+	fmt.Println(" getbalance -address ADDRESS - get the balance for an address ")
+	fmt.Println(" listtransactions -address ADDRESS - Lists the confirmed transactions that credit or debit an address")
+	fmt.Println(" createblockchain -address ADDRESS - creates a blockchain")
+	fmt.Println(" printchain -from N -to M - Prints the blocks in the chain, from the tip back or from height N to M in forward order")
+	fmt.Println(" send -from FROM -to TO -amount AMOUNT -fee FEE -locktime HEIGHT -mine=BOOL -passphrase PASS - Send amount to TO address, mining it unless -mine=false")
+	fmt.Println(" mine -address ADDRESS -blocksize BYTES - Mines a block with the best paying pending transactions, rewarding ADDRESS")
+	fmt.Println(" createwallet -mnemonic -passphrase PASS - Creates a new wallet, starting a mnemonic seed with -mnemonic and encrypting a plaintext wallet file with PASS")
+	fmt.Println(" restorewallet -mnemonic \"WORDS\" -count N - Restores the first N addresses of a mnemonic seed")
+	fmt.Println(" listaddresses - Lists the stored addresses")
+	fmt.Println(" getpubkey -address ADDRESS - Prints the public key of a wallet address")
+	fmt.Println(" createmultisig -m M -pubkeys KEY,KEY,... - Creates an address spendable with M signatures of the given public keys")
+	fmt.Println(" createmultisigtx -redeemscript SCRIPT -to TO -amount AMOUNT -fee FEE - Creates an unsigned transaction spending from a multisig address")
+	fmt.Println(" signmultisigtx -tx TX -redeemscript SCRIPT -address ADDRESS - Adds the signature of ADDRESS to a multisig transaction")
+	fmt.Println(" sendrawtx -tx TX - Submits a signed transaction to the mempool")
+	fmt.Println(" encryptwallet -passphrase PASS - Encrypts the wallet file with a passphrase")
+	fmt.Println(" changepassphrase -old OLD -new NEW - Changes the wallet passphrase")
+	fmt.Println(" unlock -passphrase PASS - Checks the passphrase by decrypting the wallet")
+	fmt.Println(" reindexutxo - Rebuilds the UTXO set")
+	fmt.Println(" supply - Prints the coins issued by the coinbases of the chain, those still unspent and the issuance schedule")
+	fmt.Println(" verifychain - Validates every block in the chain")
+	fmt.Println(" startnode -port PORT -peers HOST:PORT,... - Starts a node listening on PORT of every interface")
+	fmt.Println(" serve -rpc HOST:PORT -explorer HOST:PORT - Serves the token protected JSON-RPC API on localhost unless HOST is given, which -rpcconnect routes getbalance, createwallet, listaddresses, send and mine through, and the REST block explorer")
+	fmt.Println(" gettransaction -id TXID - Prints a transaction with its block and confirmations, faster with -txindex")
+	fmt.Println(" proveinclusion -block HASH -tx TXID - Prints a Merkle proof that TXID is in block HASH")
+	fmt.Println(" verifyproof -root ROOT -tx TXID -proof PROOF - Checks a Merkle proof against a Merkle root")
+}
+
+func (cli *CommandLine) validateArgs(args []string) bool {
+	if len(args) < 1 {
+		cli.printUsage()
+
+		return false
+	}
+
+	return true
+}
+
+func (cli *CommandLine) dir() string {
+	return cli.params.DataDir(cli.dataDir)
+}
+
+func (cli *CommandLine) openChain() (*blockchain.Blockchain, error) {
+	chain, err := blockchain.ContinueBlockchain(cli.dir(), cli.params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cli.txIndex {
+		if err := chain.EnableTxIndex(); err != nil {
+			chain.ShutdownDB()
+
+			return nil, err
+		}
+	}
+
+	return chain, nil
+}
+
+func (cli *CommandLine) openWallets() (*wallet.Wallets, error) {
+	return wallet.CreateWallets(cli.dir(), cli.params.AddressVersion)
+}
+
+func (cli *CommandLine) validateAddress(address string) error {
+	if _, err := blockchain.LockingScript(cli.params, address); err != nil {
+		return fmt.Errorf("%w: %s", err, address)
+	}
+
+	return nil
+}
+
+func exitCode(err error) int {
+	var validationErr *blockchain.ValidationError
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, blockchain.ErrChainNotFound):
+		return exitChainNotFound
+	case errors.Is(err, blockchain.ErrChainExists):
+		return exitChainExists
+	case errors.Is(err, blockchain.ErrInsufficientFunds):
+		return exitInsufficientFunds
+	case errors.Is(err, wallet.ErrUnknownWallet):
+		return exitUnknownWallet
+	case errors.Is(err, blockchain.ErrInvalidSignature):
+		return exitInvalidSignature
+	case errors.Is(err, wallet.ErrInvalidAddress):
+		return exitInvalidAddress
+	case errors.As(err, &validationErr):
+		return exitInvalidChain
+	case errors.Is(err, blockchain.ErrWrongNetwork):
+		return exitWrongNetwork
+	case errors.Is(err, blockchain.ErrUnknownNetwork):
+		return exitUsage
+	case errors.Is(err, wallet.ErrWalletLocked):
+		return exitWalletLocked
+	case errors.Is(err, wallet.ErrWrongPassphrase):
+		return exitWrongPassphrase
+	default:
+		return exitFailure
+	}
+}
+
+func (cli *CommandLine) printChain(from, to int) error {
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	if from < 0 && to < 0 {
+		it := chain.Iterator()
+
+		for {
+			block, err := it.Next()
+
+			if err != nil {
+				return err
+			}
+
+			if err := printBlock(chain, block); err != nil {
+				return err
+			}
+
+			if len(block.PrevHash) == 0 {
+				return nil
+			}
+		}
+	}
+
+	best, err := chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	if from < 0 {
+		from = 0
+	}
+
+	if to < 0 || to > best {
+		to = best
+	}
+
+	for height := from; height <= to; height++ {
+		block, err := chain.GetBlockByHeight(height)
+
+		if err != nil {
+			return err
+		}
+
+		if err := printBlock(chain, block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printBlock(chain *blockchain.Blockchain, block *blockchain.Block) error {
+	bits, err := chain.ExpectedBits(block)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Hash: %x\n", block.Hash)
+	fmt.Printf("Height: %d\n", block.Height)
+	fmt.Printf("Timestamp: %s\n", time.Unix(block.Timestamp, 0).UTC().Format(time.RFC3339))
+	fmt.Printf("Previous hash: %x\n", block.PrevHash)
+	fmt.Printf("Merkle root: %x\n", block.MerkleRoot)
+	fmt.Printf("Difficulty: %d\n", block.Bits)
+	pow := blockchain.NewProof(block, bits)
+	fmt.Printf("Validated: %s\n", strconv.FormatBool(pow.Validate()))
+	for _, tx := range block.Transactions {
+		fmt.Println(tx)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func (cli *CommandLine) createBlockchain(address string) error {
+	if err := cli.validateAddress(address); err != nil {
+		return err
+	}
+
+	chain, err := blockchain.InitBlockchain(cli.dir(), cli.params, address, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if cli.txIndex {
+		if err := chain.EnableTxIndex(); err != nil {
+			chain.ShutdownDB()
+
+			return err
+		}
+	}
+
+	if err := chain.ShutdownDB(); err != nil {
+		return err
+	}
+
+	fmt.Println("Finished!")
+
+	return nil
+}
+
+func (cli *CommandLine) getBalance(address string) error {
+	if cli.client != nil {
+		return cli.remoteGetBalance(address)
+	}
+
+	lockingScript, err := blockchain.LockingScript(cli.params, address)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, address)
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	spendable, immature, err := chain.GetBalance(lockingScript)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Balance of %s: %d\n", address, spendable)
+	fmt.Printf("Immature: %d\n", immature)
+
+	return nil
+}
+
+func (cli *CommandLine) listTransactions(address string) error {
+	lockingScript, err := blockchain.LockingScript(cli.params, address)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, address)
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	history, err := chain.GetAddressTransactions(lockingScript)
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range history {
+		counterparties := strings.Join(entry.Counterparties, ", ")
+
+		if entry.Coinbase {
+			counterparties = "coinbase"
+		} else if counterparties == "" {
+			counterparties = "none"
+		}
+
+		fmt.Printf("Transaction: %x\n", entry.TxID)
+		fmt.Printf("Height: %d\n", entry.Height)
+		fmt.Printf("Timestamp: %s\n", time.Unix(entry.Timestamp, 0).UTC().Format(time.RFC3339))
+		fmt.Printf("Received: %d\n", entry.Received)
+		fmt.Printf("Sent: %d\n", entry.Sent)
+		fmt.Printf("Counterparties: %s\n", counterparties)
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func printHashrate(hashesPerSecond float64) {
+	fmt.Printf("\rMining at %.0f H/s", hashesPerSecond)
+}
+
+func (cli *CommandLine) send(from, to string, amount, fee, lockTime int, mineNow bool, passphrase string) error {
+	if cli.client != nil {
+		return cli.remoteSend(from, to, amount, fee, lockTime, passphrase)
+	}
+
+	if err := cli.validateAddress(from); err != nil {
+		return err
+	}
+
+	if err := cli.validateAddress(to); err != nil {
+		return err
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	pool, err := blockchain.NewMempool(chain)
+
+	if err != nil {
+		return err
+	}
+
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if passphrase, err = askPassphrase(wallets, passphrase); err != nil {
+		return err
+	}
+
+	tx, err := blockchain.NewTransaction(wallets, from, to, amount, fee, lockTime, passphrase, chain, pool)
+
+	if err != nil {
+		return err
+	}
+
+	if err := pool.Add(tx); err != nil {
+		if !errors.Is(err, blockchain.ErrNonFinal) {
+			return err
+		}
+
+		fmt.Printf("Transaction: %x\n", tx.Serialize())
+		fmt.Println("The transaction cannot be mined yet, submit it with sendrawtx once the lock time passes")
+
+		return nil
+	}
+
+	if !mineNow {
+		fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+
+		return nil
+	}
+
+	chain.Miner.OnHashrate = printHashrate
+
+	if _, err := chain.MinePending(context.Background(), pool, from, 0); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Success!")
+
+	return nil
 }
 
-func (cli *CommandLine) printUsage() {
-	fmt.Println("Usage: ")
-	fmt.Println(" getbalance -address ADDRESS - get the balance for an address ")
-	fmt.Println(" createblockchain -address ADDRESS - creates a blockchain")
-	fmt.Println(" printchain - Prints the blocks in the chain")
-	fmt.Println(" send -from FROM -to TO -amount AMOUNT - Send amount to TO address")
-	fmt.Println(" createwallet - Creates a new wallet")
-	fmt.Println(" listaddresses - Lists the stored addresses")
+func (cli *CommandLine) mine(address string, maxBlockSize int) error {
+	if cli.client != nil {
+		return cli.remoteMine(address, maxBlockSize)
+	}
+
+	if err := cli.validateAddress(address); err != nil {
+		return err
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	pool, err := blockchain.NewMempool(chain)
+
+	if err != nil {
+		return err
+	}
+
+	chain.Miner.OnHashrate = printHashrate
+
+	block, err := chain.MinePending(context.Background(), pool, address, maxBlockSize)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("Mined block %x with %d pending transactions\n", block.Hash, len(block.Transactions)-1)
+	fmt.Printf("Collected %d in fees\n", block.Transactions[0].Outputs[0].Value-chain.Params.Subsidy(block.Height))
+
+	return nil
+}
+
+func (cli *CommandLine) listAddresses() error {
+	if cli.client != nil {
+		return cli.remoteListAddresses()
+	}
+
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	addresses := wallets.GetAllAddresses()
+
+	for _, address := range addresses {
+		fmt.Println(address)
+	}
+
+	return nil
+}
+
+func (cli *CommandLine) createWallet(passphrase string, withMnemonic bool) error {
+	if cli.client != nil {
+		return cli.remoteCreateWallet(passphrase, withMnemonic)
+	}
+
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if err := unlockOrEncryptWallets(wallets, passphrase); err != nil {
+		return err
+	}
+
+	if withMnemonic {
+		mnemonic, err := wallet.NewMnemonic()
+
+		if err != nil {
+			return err
+		}
+
+		if err := wallets.SetMnemonic(mnemonic); err != nil {
+			return err
+		}
+
+		fmt.Printf("Mnemonic: %s\n", mnemonic)
+		fmt.Println("Write these words down, they are the only backup of the wallet seed")
+	}
+
+	newWallet, err := wallets.AddWallet()
+
+	if err != nil {
+		return err
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		return err
+	}
+
+	fmt.Printf("New address: %s\n", newWallet)
+
+	return nil
+}
+
+func (cli *CommandLine) restoreWallet(mnemonic string, count int, passphrase string) error {
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if err := unlockOrEncryptWallets(wallets, passphrase); err != nil {
+		return err
+	}
+
+	if err := wallets.SetMnemonic(mnemonic); err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		address, err := wallets.AddWallet()
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored address: %s\n", address)
+	}
+
+	return wallets.SaveFile()
+}
+
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	line, err := stdin.ReadString('\n')
+
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func askPassphrase(wallets *wallet.Wallets, passphrase string) (string, error) {
+	if passphrase != "" || !wallets.IsEncrypted() {
+		return passphrase, nil
+	}
+
+	return readPassphrase("Wallet passphrase: ")
+}
+
+func unlockWallets(wallets *wallet.Wallets, passphrase string) error {
+	passphrase, err := askPassphrase(wallets, passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	return wallets.Unlock(passphrase)
+}
+
+func unlockOrEncryptWallets(wallets *wallet.Wallets, passphrase string) error {
+	if wallets.IsEncrypted() {
+		return unlockWallets(wallets, passphrase)
+	}
+
+	passphrase, err := askNewPassphrase(passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	return wallets.Encrypt(passphrase)
+}
+
+func askNewPassphrase(passphrase string) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := readPassphrase("New passphrase: ")
+
+	if err != nil {
+		return "", err
+	}
+
+	repeated, err := readPassphrase("Repeat new passphrase: ")
+
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase != repeated {
+		return "", errors.New("passphrases do not match")
+	}
+
+	return passphrase, nil
+}
+
+func (cli *CommandLine) encryptWallet(passphrase string) error {
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if wallets.IsEncrypted() {
+		return wallet.ErrWalletEncrypted
+	}
+
+	if passphrase, err = askNewPassphrase(passphrase); err != nil {
+		return err
+	}
+
+	if err := wallets.Encrypt(passphrase); err != nil {
+		return err
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		return err
+	}
+
+	fmt.Println("Wallet encrypted")
+
+	return nil
+}
+
+func (cli *CommandLine) changePassphrase(oldPassphrase, newPassphrase string) error {
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if !wallets.IsEncrypted() {
+		return wallet.ErrWalletNotEncrypted
+	}
+
+	if oldPassphrase, err = askPassphrase(wallets, oldPassphrase); err != nil {
+		return err
+	}
+
+	if err := wallets.Unlock(oldPassphrase); err != nil {
+		return err
+	}
+
+	if newPassphrase, err = askNewPassphrase(newPassphrase); err != nil {
+		return err
+	}
+
+	if err := wallets.ChangePassphrase(oldPassphrase, newPassphrase); err != nil {
+		return err
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		return err
+	}
+
+	fmt.Println("Passphrase changed")
+
+	return nil
+}
+
+func (cli *CommandLine) unlock(passphrase string) error {
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if !wallets.IsEncrypted() {
+		return wallet.ErrWalletNotEncrypted
+	}
+
+	if err := unlockWallets(wallets, passphrase); err != nil {
+		return err
+	}
+
+	fmt.Printf("Passphrase accepted, %d keys decrypted\n", len(wallets.GetAllAddresses()))
+
+	return nil
+}
+
+func (cli *CommandLine) supply() error {
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	height, err := chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	issued, err := chain.Issued()
+
+	if err != nil {
+		return err
+	}
+
+	circulating, err := chain.Circulating()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Height: %d\n", height)
+	fmt.Printf("Issued: %d\n", issued)
+	fmt.Printf("Circulating: %d\n", circulating)
+	fmt.Printf("Scheduled issuance: %d\n", chain.Params.IssuedAt(height))
+	fmt.Printf("Next block subsidy: %d\n", chain.Params.Subsidy(height+1))
+	fmt.Printf("Maximum supply: %d\n", chain.Params.MaxSupply())
+
+	return nil
+}
+
+func (cli *CommandLine) reindexUTXO() error {
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	if err := chain.ReindexUTXO(); err != nil {
+		return err
+	}
+
+	count, err := chain.CountUTXO()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Done! There are %d unspent outputs in the UTXO set.\n", count)
+
+	return nil
 }
 
-func (cli *CommandLine) validateArgs() {
-	if len(os.Args) < 2 {
-		cli.printUsage()
-		runtime.Goexit()
+func (cli *CommandLine) verifyChain() error {
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	block, err := chain.ValidateChain()
+
+	if err != nil {
+		if block != nil {
+			fmt.Printf("Invalid block %x at height %d\n", block.Hash, block.Height)
+		}
+
+		return err
 	}
+
+	fmt.Println("Chain is valid")
+
+	return nil
 }
 
-func (cli *CommandLine) printChain() {
-	chain := blockchain.ContinueBlockchain("")
+func (cli *CommandLine) startNode(port int, peers string) error {
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
 	defer chain.ShutdownDB()
 
-	it := chain.Iterator()
+	server, err := network.NewServer(chain, fmt.Sprintf(":%d", port))
+
+	if err != nil {
+		return err
+	}
+
+	if err := server.Start(); err != nil {
+		return err
+	}
 
-	for {
-		block := it.Next()
+	fmt.Printf("Node listening on %s\n", server.Address)
 
-		fmt.Printf("Hash: %x\n", block.Hash)
-		fmt.Printf("Previous hash: %x\n", block.PrevHash)
-		pow := blockchain.NewProof(block)
-		fmt.Printf("Validated: %s\n", strconv.FormatBool(pow.Validate()))
-		for _, tx := range block.Transactions {
-			fmt.Println(tx)
+	for _, peer := range strings.Split(peers, ",") {
+		if peer == "" {
+			continue
 		}
-		fmt.Println()
 
-		if len(block.PrevHash) == 0 {
-			break
+		if err := server.Connect(peer); err != nil {
+			fmt.Printf("Could not connect to %s: %s\n", peer, err)
 		}
 	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	return server.Close()
 }
 
-func (cli *CommandLine) createBlockchain(address string) {
-	if !wallet.ValidateAddress(address) {
-		log.Panic("Invalid address")
-	}
+func (cli *CommandLine) getTransaction(txID string) error {
+	ID, err := hex.DecodeString(txID)
 
-	chain := blockchain.InitBlockchain(address)
-	chain.ShutdownDB()
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
 
-	fmt.Println("Finished!")
-}
+	chain, err := cli.openChain()
 
-func (cli *CommandLine) getBalance(address string) {
-	if !wallet.ValidateAddress(address) {
-		log.Panic("Invalid address")
+	if err != nil {
+		return err
 	}
 
-	chain := blockchain.ContinueBlockchain(address)
 	defer chain.ShutdownDB()
 
-	balance := 0
-	pubKeyHash := wallet.Base58Decode([]byte(address))
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
-	UTXOs := chain.FindUTXO(pubKeyHash)
+	block, position, err := chain.LocateTransaction(ID)
+
+	if errors.Is(err, blockchain.ErrTxNotFound) {
+		pool, poolErr := blockchain.NewMempool(chain)
+
+		if poolErr != nil {
+			return poolErr
+		}
+
+		if tx, ok := pool.Get(ID); ok {
+			fmt.Println(tx)
+			fmt.Println("Block: none, the transaction is in the mempool")
+			fmt.Println("Confirmations: 0")
+
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	best, err := chain.GetBestHeight()
 
-	for _, out := range UTXOs {
-		balance += out.Value
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Balance of %s: %d\n", address, balance)
+	fmt.Println(block.Transactions[position])
+	fmt.Printf("Block: %x\n", block.Hash)
+	fmt.Printf("Height: %d\n", block.Height)
+	fmt.Printf("Position: %d\n", position)
+	fmt.Printf("Confirmations: %d\n", best-block.Height+1)
+
+	return nil
 }
 
-func (cli *CommandLine) send(from, to string, amount int) {
-	if !wallet.ValidateAddress(from) {
-		log.Panic("Invalid from address")
+func (cli *CommandLine) proveInclusion(blockHash, txID string) error {
+	hash, err := hex.DecodeString(blockHash)
+
+	if err != nil {
+		return fmt.Errorf("invalid block hash: %w", err)
 	}
 
-	if !wallet.ValidateAddress(to) {
-		log.Panic("Invalid to address")
+	ID, err := hex.DecodeString(txID)
+
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
 	}
 
-	chain := blockchain.ContinueBlockchain(from)
 	defer chain.ShutdownDB()
 
-	tx := blockchain.NewTransaction(from, to, amount, chain)
-	chain.AddBlock([]*blockchain.Transaction{tx})
-	fmt.Println("Success!")
-}
+	block, err := chain.GetBlock(hash)
 
-func (cli *CommandLine) listAddresses() {
-	wallets, _ := wallet.CreateWallets()
-	addresses := wallets.GetAllAddresses()
+	if err != nil {
+		return err
+	}
 
-	for _, address := range addresses {
-		fmt.Println(address)
+	proof, err := block.MerkleProof(ID)
+
+	if err != nil {
+		return err
 	}
+
+	fmt.Printf("Merkle root: %x\n", block.MerkleRoot)
+	fmt.Printf("Proof: %x\n", proof.Serialize())
+
+	return nil
 }
 
-func (cli *CommandLine) createWallet() {
-	wallets, _ := wallet.CreateWallets()
-	newWallet := wallets.AddWallet()
-	wallets.SaveFile()
+func (cli *CommandLine) verifyProof(root, txID, encodedProof string) error {
+	rootHash, err := hex.DecodeString(root)
 
-	fmt.Printf("New address: %s\n", newWallet)
+	if err != nil {
+		return fmt.Errorf("invalid Merkle root: %w", err)
+	}
+
+	ID, err := hex.DecodeString(txID)
+
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
+
+	if len(ID) != sha256.Size {
+		return fmt.Errorf("invalid transaction ID: got %d bytes, want %d", len(ID), sha256.Size)
+	}
+
+	data, err := hex.DecodeString(encodedProof)
+
+	if err != nil {
+		return fmt.Errorf("invalid proof: %w", err)
+	}
+
+	proof, err := merkle.DeserializeProof(data)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Valid: %s\n", strconv.FormatBool(proof.Verify(rootHash, ID)))
+
+	return nil
 }
 
-func (cli *CommandLine) Run() {
-	cli.validateArgs()
+func (cli *CommandLine) Run() int {
+	globalFlags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	globalFlags.Usage = cli.printUsage
+	dataDir := globalFlags.String("datadir", defaultDataDir, "The directory holding the chain and wallet data")
+	network := globalFlags.String("network", blockchain.MainNetParams.Name, "The network to use: main, test or regtest")
+	rpcConnect := globalFlags.String("rpcconnect", "", "Run the command through the RPC server at this address")
+	rpcToken := globalFlags.String("rpctoken", "", "The RPC authentication token, read from the cookie file of the data directory when empty")
+	txIndex := globalFlags.Bool("txindex", false, "Build and keep an index of every transaction, which stays on once built")
+
+	if err := globalFlags.Parse(os.Args[1:]); err != nil {
+		return exitUsage
+	}
+
+	params, err := blockchain.NetworkParams(*network)
+
+	if err != nil {
+		return cli.report(err)
+	}
+
+	cli.dataDir = *dataDir
+	cli.params = params
+	cli.rpcToken = *rpcToken
+	cli.txIndex = *txIndex
+	args := globalFlags.Args()
+
+	if !cli.validateArgs(args) {
+		return exitUsage
+	}
+
+	if *rpcConnect != "" {
+		if !remoteCommands[args[0]] {
+			return cli.report(fmt.Errorf("%s cannot be run through the RPC server", args[0]))
+		}
+
+		token, err := cli.clientToken()
+
+		if err != nil {
+			return cli.report(err)
+		}
+
+		cli.client = rpc.NewClient(*rpcConnect, token)
+	}
 
-	getBalanceCmd := flag.NewFlagSet("getbalance", flag.ExitOnError)
+	getBalanceCmd := flag.NewFlagSet("getbalance", flag.ContinueOnError)
 	getBalanceAddress := getBalanceCmd.String("address", "", "The address to get the balance from")
 
-	createBlockchainCmd := flag.NewFlagSet("createblockchain", flag.ExitOnError)
+	listTransactionsCmd := flag.NewFlagSet("listtransactions", flag.ContinueOnError)
+	listTransactionsAddress := listTransactionsCmd.String("address", "", "The address to list the transactions of")
+
+	createBlockchainCmd := flag.NewFlagSet("createblockchain", flag.ContinueOnError)
 	createBlockchainAddress := createBlockchainCmd.String("address", "", "The address to send the coinbase tx")
 
-	sendCmd := flag.NewFlagSet("send", flag.ExitOnError)
+	sendCmd := flag.NewFlagSet("send", flag.ContinueOnError)
 	sendFromCmd := sendCmd.String("from", "", "The address that is sending the tokens")
 	sendToCmd := sendCmd.String("to", "", "The address that is receiving the tokens")
 	sendAmountCmd := sendCmd.Int("amount", 0, "The amount being sent")
+	sendFeeCmd := sendCmd.Int("fee", 0, "The fee paid to the miner of the transaction")
+	sendLockTime := sendCmd.Int("locktime", 0, "The block height (or Unix time) before which the transaction cannot be mined")
+	sendMineCmd := sendCmd.Bool("mine", true, "Mine the transaction right away instead of leaving it in the mempool")
+	sendPassphrase := sendCmd.String("passphrase", "", "The wallet passphrase, prompted for when empty")
+
+	mineCmd := flag.NewFlagSet("mine", flag.ContinueOnError)
+	mineAddress := mineCmd.String("address", "", "The address that receives the block reward")
+	mineBlockSize := mineCmd.Int("blocksize", 0, "The maximum size of the mined block in bytes, the network limit when 0")
+
+	printChainCmd := flag.NewFlagSet("printchain", flag.ContinueOnError)
+	printChainFrom := printChainCmd.Int("from", -1, "The height of the first block to print, in forward order")
+	printChainTo := printChainCmd.Int("to", -1, "The height of the last block to print, the tip when omitted")
+
+	createWalletCmd := flag.NewFlagSet("createwallet", flag.ContinueOnError)
+	createWalletPassphrase := createWalletCmd.String("passphrase", "", "The wallet passphrase, prompted for when empty")
+	createWalletMnemonic := createWalletCmd.Bool("mnemonic", false, "Generate a mnemonic seed and derive addresses from it")
+
+	restoreWalletCmd := flag.NewFlagSet("restorewallet", flag.ContinueOnError)
+	restoreWalletMnemonic := restoreWalletCmd.String("mnemonic", "", "The mnemonic words of the seed to restore")
+	restoreWalletCount := restoreWalletCmd.Int("count", 1, "The number of addresses to derive")
+	restoreWalletPassphrase := restoreWalletCmd.String("passphrase", "", "The wallet passphrase, prompted for when empty")
+
+	encryptWalletCmd := flag.NewFlagSet("encryptwallet", flag.ContinueOnError)
+	encryptWalletPassphrase := encryptWalletCmd.String("passphrase", "", "The new wallet passphrase, prompted for when empty")
+
+	changePassphraseCmd := flag.NewFlagSet("changepassphrase", flag.ContinueOnError)
+	changePassphraseOld := changePassphraseCmd.String("old", "", "The current wallet passphrase, prompted for when empty")
+	changePassphraseNew := changePassphraseCmd.String("new", "", "The new wallet passphrase, prompted for when empty")
+
+	unlockCmd := flag.NewFlagSet("unlock", flag.ContinueOnError)
+	unlockPassphrase := unlockCmd.String("passphrase", "", "The wallet passphrase, prompted for when empty")
+
+	listAddressesCmd := flag.NewFlagSet("listaddresses", flag.ContinueOnError)
+
+	getPubKeyCmd := flag.NewFlagSet("getpubkey", flag.ContinueOnError)
+	getPubKeyAddress := getPubKeyCmd.String("address", "", "The wallet address")
+	getPubKeyPassphrase := getPubKeyCmd.String("passphrase", "", "The wallet passphrase, prompted for when empty")
+
+	createMultiSigCmd := flag.NewFlagSet("createmultisig", flag.ContinueOnError)
+	createMultiSigRequired := createMultiSigCmd.Int("m", 0, "The number of signatures required to spend")
+	createMultiSigPubKeys := createMultiSigCmd.String("pubkeys", "", "Comma separated public keys allowed to sign")
+
+	createMultiSigTxCmd := flag.NewFlagSet("createmultisigtx", flag.ContinueOnError)
+	createMultiSigTxScript := createMultiSigTxCmd.String("redeemscript", "", "The redeem script printed by createmultisig")
+	createMultiSigTxTo := createMultiSigTxCmd.String("to", "", "The address that is receiving the tokens")
+	createMultiSigTxAmount := createMultiSigTxCmd.Int("amount", 0, "The amount being sent")
+	createMultiSigTxFee := createMultiSigTxCmd.Int("fee", 0, "The fee paid to the miner of the transaction")
+
+	signMultiSigTxCmd := flag.NewFlagSet("signmultisigtx", flag.ContinueOnError)
+	signMultiSigTxTx := signMultiSigTxCmd.String("tx", "", "The serialized transaction")
+	signMultiSigTxScript := signMultiSigTxCmd.String("redeemscript", "", "The redeem script printed by createmultisig")
+	signMultiSigTxAddress := signMultiSigTxCmd.String("address", "", "The wallet address signing the transaction")
+	signMultiSigTxPassphrase := signMultiSigTxCmd.String("passphrase", "", "The wallet passphrase, prompted for when empty")
+
+	sendRawTxCmd := flag.NewFlagSet("sendrawtx", flag.ContinueOnError)
+	sendRawTxTx := sendRawTxCmd.String("tx", "", "The serialized transaction")
+
+	reindexUTXOCmd := flag.NewFlagSet("reindexutxo", flag.ContinueOnError)
+	supplyCmd := flag.NewFlagSet("supply", flag.ContinueOnError)
+	verifyChainCmd := flag.NewFlagSet("verifychain", flag.ContinueOnError)
+
+	startNodeCmd := flag.NewFlagSet("startnode", flag.ContinueOnError)
+	startNodePort := startNodeCmd.Int("port", 0, "The port the node listens on")
+	startNodePeers := startNodeCmd.String("peers", "", "Comma separated addresses of peers to connect to")
 
-	printChainCmd := flag.NewFlagSet("printchain", flag.ExitOnError)
+	serveCmd := flag.NewFlagSet("serve", flag.ContinueOnError)
+	serveRPC := serveCmd.String("rpc", "", "The address the JSON-RPC server listens on, like :8332 for localhost or 0.0.0.0:8332 for every interface")
+	serveExplorer := serveCmd.String("explorer", "", "The address the REST block explorer listens on, like :8080")
 
-	createWalletCmd := flag.NewFlagSet("createwallet", flag.ExitOnError)
-	listAddressesCmd := flag.NewFlagSet("listaddresses", flag.ExitOnError)
+	getTransactionCmd := flag.NewFlagSet("gettransaction", flag.ContinueOnError)
+	getTransactionID := getTransactionCmd.String("id", "", "The ID of the transaction")
 
-	switch os.Args[1] {
+	proveInclusionCmd := flag.NewFlagSet("proveinclusion", flag.ContinueOnError)
+	proveInclusionBlock := proveInclusionCmd.String("block", "", "The hash of the block containing the transaction")
+	proveInclusionTx := proveInclusionCmd.String("tx", "", "The ID of the transaction to prove")
+
+	verifyProofCmd := flag.NewFlagSet("verifyproof", flag.ContinueOnError)
+	verifyProofRoot := verifyProofCmd.String("root", "", "The Merkle root of the block")
+	verifyProofTx := verifyProofCmd.String("tx", "", "The ID of the transaction being proven")
+	verifyProofProof := verifyProofCmd.String("proof", "", "The proof printed by proveinclusion")
+
+	switch args[0] {
 	case "getbalance":
-		err := getBalanceCmd.Parse(os.Args[2:])
+		err := getBalanceCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "listtransactions":
+		err := listTransactionsCmd.Parse(args[1:])
 
 		if err != nil {
-			log.Panic(err)
+			return exitUsage
 		}
 	case "createblockchain":
-		err := createBlockchainCmd.Parse(os.Args[2:])
+		err := createBlockchainCmd.Parse(args[1:])
 
 		if err != nil {
-			log.Panic(err)
+			return exitUsage
 		}
 	case "send":
-		err := sendCmd.Parse(os.Args[2:])
+		err := sendCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "mine":
+		err := mineCmd.Parse(args[1:])
 
 		if err != nil {
-			log.Panic(err)
+			return exitUsage
 		}
 	case "printchain":
-		err := printChainCmd.Parse(os.Args[2:])
+		err := printChainCmd.Parse(args[1:])
 
 		if err != nil {
-			log.Panic(err)
+			return exitUsage
 		}
 	case "createwallet":
-		err := createWalletCmd.Parse(os.Args[2:])
+		err := createWalletCmd.Parse(args[1:])
 
 		if err != nil {
-			log.Panic(err)
+			return exitUsage
 		}
 	case "listaddresses":
-		err := listAddressesCmd.Parse(os.Args[2:])
+		err := listAddressesCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "getpubkey":
+		err := getPubKeyCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "createmultisig":
+		err := createMultiSigCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "createmultisigtx":
+		err := createMultiSigTxCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "signmultisigtx":
+		err := signMultiSigTxCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "sendrawtx":
+		err := sendRawTxCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "restorewallet":
+		err := restoreWalletCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "encryptwallet":
+		err := encryptWalletCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "changepassphrase":
+		err := changePassphraseCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "unlock":
+		err := unlockCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "reindexutxo":
+		err := reindexUTXOCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "supply":
+		err := supplyCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "verifychain":
+		err := verifyChainCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "startnode":
+		err := startNodeCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "serve":
+		err := serveCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "gettransaction":
+		err := getTransactionCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
+		}
+	case "proveinclusion":
+		err := proveInclusionCmd.Parse(args[1:])
 
 		if err != nil {
-			log.Panic(err)
+			return exitUsage
+		}
+	case "verifyproof":
+		err := verifyProofCmd.Parse(args[1:])
+
+		if err != nil {
+			return exitUsage
 		}
 	default:
 		cli.printUsage()
-		runtime.Goexit()
+
+		return exitUsage
 	}
 
 	if getBalanceCmd.Parsed() {
 		if *getBalanceAddress == "" {
 			getBalanceCmd.Usage()
-			runtime.Goexit()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.getBalance(*getBalanceAddress))
+	}
+
+	if listTransactionsCmd.Parsed() {
+		if *listTransactionsAddress == "" {
+			listTransactionsCmd.Usage()
+
+			return exitUsage
 		}
 
-		cli.getBalance(*getBalanceAddress)
+		return cli.report(cli.listTransactions(*listTransactionsAddress))
 	}
 
 	if createBlockchainCmd.Parsed() {
 		if *createBlockchainAddress == "" {
 			createBlockchainCmd.Usage()
-			runtime.Goexit()
+
+			return exitUsage
 		}
 
-		cli.createBlockchain(*createBlockchainAddress)
+		return cli.report(cli.createBlockchain(*createBlockchainAddress))
 	}
 
 	if sendCmd.Parsed() {
 		if *sendFromCmd == "" || *sendToCmd == "" || *sendAmountCmd == 0 {
 			sendCmd.Usage()
-			runtime.Goexit()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.send(*sendFromCmd, *sendToCmd, *sendAmountCmd, *sendFeeCmd, *sendLockTime, *sendMineCmd, *sendPassphrase))
+	}
+
+	if mineCmd.Parsed() {
+		if *mineAddress == "" {
+			mineCmd.Usage()
+
+			return exitUsage
 		}
 
-		cli.send(*sendFromCmd, *sendToCmd, *sendAmountCmd)
+		return cli.report(cli.mine(*mineAddress, *mineBlockSize))
 	}
 
 	if printChainCmd.Parsed() {
-		cli.printChain()
+		if *printChainTo >= 0 && *printChainFrom > *printChainTo {
+			printChainCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.printChain(*printChainFrom, *printChainTo))
 	}
 
 	if createWalletCmd.Parsed() {
-		cli.createWallet()
+		return cli.report(cli.createWallet(*createWalletPassphrase, *createWalletMnemonic))
+	}
+
+	if restoreWalletCmd.Parsed() {
+		if *restoreWalletMnemonic == "" || *restoreWalletCount < 1 {
+			restoreWalletCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.restoreWallet(*restoreWalletMnemonic, *restoreWalletCount, *restoreWalletPassphrase))
+	}
+
+	if encryptWalletCmd.Parsed() {
+		return cli.report(cli.encryptWallet(*encryptWalletPassphrase))
+	}
+
+	if changePassphraseCmd.Parsed() {
+		return cli.report(cli.changePassphrase(*changePassphraseOld, *changePassphraseNew))
+	}
+
+	if unlockCmd.Parsed() {
+		return cli.report(cli.unlock(*unlockPassphrase))
 	}
 
 	if listAddressesCmd.Parsed() {
-		cli.listAddresses()
+		return cli.report(cli.listAddresses())
+	}
+
+	if getPubKeyCmd.Parsed() {
+		if *getPubKeyAddress == "" {
+			getPubKeyCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.getPubKey(*getPubKeyAddress, *getPubKeyPassphrase))
+	}
+
+	if createMultiSigCmd.Parsed() {
+		if *createMultiSigRequired < 1 || *createMultiSigPubKeys == "" {
+			createMultiSigCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.createMultiSig(*createMultiSigRequired, *createMultiSigPubKeys))
+	}
+
+	if createMultiSigTxCmd.Parsed() {
+		if *createMultiSigTxScript == "" || *createMultiSigTxTo == "" || *createMultiSigTxAmount == 0 {
+			createMultiSigTxCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.createMultiSigTx(*createMultiSigTxScript, *createMultiSigTxTo, *createMultiSigTxAmount, *createMultiSigTxFee))
+	}
+
+	if signMultiSigTxCmd.Parsed() {
+		if *signMultiSigTxTx == "" || *signMultiSigTxScript == "" || *signMultiSigTxAddress == "" {
+			signMultiSigTxCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.signMultiSigTx(*signMultiSigTxTx, *signMultiSigTxScript, *signMultiSigTxAddress, *signMultiSigTxPassphrase))
+	}
+
+	if sendRawTxCmd.Parsed() {
+		if *sendRawTxTx == "" {
+			sendRawTxCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.sendRawTx(*sendRawTxTx))
 	}
+
+	if reindexUTXOCmd.Parsed() {
+		return cli.report(cli.reindexUTXO())
+	}
+
+	if supplyCmd.Parsed() {
+		return cli.report(cli.supply())
+	}
+
+	if verifyChainCmd.Parsed() {
+		return cli.report(cli.verifyChain())
+	}
+
+	if startNodeCmd.Parsed() {
+		if *startNodePort == 0 {
+			startNodeCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.startNode(*startNodePort, *startNodePeers))
+	}
+
+	if serveCmd.Parsed() {
+		if *serveRPC == "" && *serveExplorer == "" {
+			serveCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.serve(*serveRPC, *serveExplorer))
+	}
+
+	if getTransactionCmd.Parsed() {
+		if *getTransactionID == "" {
+			getTransactionCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.getTransaction(*getTransactionID))
+	}
+
+	if proveInclusionCmd.Parsed() {
+		if *proveInclusionBlock == "" || *proveInclusionTx == "" {
+			proveInclusionCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.proveInclusion(*proveInclusionBlock, *proveInclusionTx))
+	}
+
+	if verifyProofCmd.Parsed() {
+		if *verifyProofRoot == "" || *verifyProofTx == "" || *verifyProofProof == "" {
+			verifyProofCmd.Usage()
+
+			return exitUsage
+		}
+
+		return cli.report(cli.verifyProof(*verifyProofRoot, *verifyProofTx, *verifyProofProof))
+	}
+
+	return exitOK
 }
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/script"
+	"strings"
+)
+
+func decodeTransaction(encoded string) (*blockchain.Transaction, error) {
+	data, err := hex.DecodeString(encoded)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+
+	tx, err := blockchain.DeserializeTransaction(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+
+	return &tx, nil
+}
+
+func decodeRedeemScript(encoded string) ([]byte, error) {
+	redeemScript, err := hex.DecodeString(encoded)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid redeem script: %w", err)
+	}
+
+	if _, _, ok := script.ExtractMultiSig(redeemScript); !ok {
+		return nil, fmt.Errorf("%w: not a multisig script", script.ErrInvalidScript)
+	}
+
+	return redeemScript, nil
+}
+
+func (cli *CommandLine) getPubKey(address, passphrase string) error {
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if err := unlockWallets(wallets, passphrase); err != nil {
+		return err
+	}
+
+	w, err := wallets.GetWallet(address)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Public key: %x\n", w.PublicKey)
+
+	return nil
+}
+
+func (cli *CommandLine) createMultiSig(required int, encodedKeys string) error {
+	var pubKeys [][]byte
+
+	for _, encoded := range strings.Split(encodedKeys, ",") {
+		pubKey, err := hex.DecodeString(strings.TrimSpace(encoded))
+
+		if err != nil {
+			return fmt.Errorf("invalid public key %q: %w", encoded, err)
+		}
+
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	redeemScript, err := script.MultiSig(required, pubKeys)
+
+	if err != nil {
+		return err
+	}
+
+	address, err := blockchain.MultiSigAddress(cli.params, redeemScript)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address: %s\n", address)
+	fmt.Printf("Redeem script: %x\n", redeemScript)
+
+	return nil
+}
+
+func (cli *CommandLine) createMultiSigTx(encodedScript, to string, amount, fee int) error {
+	redeemScript, err := decodeRedeemScript(encodedScript)
+
+	if err != nil {
+		return err
+	}
+
+	if err := cli.validateAddress(to); err != nil {
+		return err
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	pool, err := blockchain.NewMempool(chain)
+
+	if err != nil {
+		return err
+	}
+
+	from := script.PayToScriptHash(script.Hash160(redeemScript))
+	tx, err := blockchain.NewUnsignedTransaction(from, to, amount, fee, 0, chain, pool)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction: %x\n", tx.Serialize())
+
+	return nil
+}
+
+func (cli *CommandLine) signMultiSigTx(encodedTx, encodedScript, address, passphrase string) error {
+	tx, err := decodeTransaction(encodedTx)
+
+	if err != nil {
+		return err
+	}
+
+	redeemScript, err := decodeRedeemScript(encodedScript)
+
+	if err != nil {
+		return err
+	}
+
+	wallets, err := cli.openWallets()
+
+	if err != nil {
+		return err
+	}
+
+	if err := unlockWallets(wallets, passphrase); err != nil {
+		return err
+	}
+
+	w, err := wallets.GetWallet(address)
+
+	if err != nil {
+		return err
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	if err := chain.SignMultiSigTransaction(tx, *w.PrivateKey, redeemScript); err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction: %x\n", tx.Serialize())
+
+	if err := chain.VerifyTransaction(tx); err != nil {
+		fmt.Println("More signatures are needed")
+	} else {
+		fmt.Println("The transaction is fully signed")
+	}
+
+	return nil
+}
+
+func (cli *CommandLine) sendRawTx(encodedTx string) error {
+	tx, err := decodeTransaction(encodedTx)
+
+	if err != nil {
+		return err
+	}
+
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	pool, err := blockchain.NewMempool(chain)
+
+	if err != nil {
+		return err
+	}
+
+	if err := pool.Add(tx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction %x added to the mempool\n", tx.ID)
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/explorer"
+	"github.com/e-aleixandre/go-blockchain/rpc"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const rpcCookieFile = "rpc.cookie"
+
+var remoteCommands = map[string]bool{
+	"getbalance":    true,
+	"createwallet":  true,
+	"listaddresses": true,
+	"send":          true,
+	"mine":          true,
+}
+
+func (cli *CommandLine) cookiePath() string {
+	return filepath.Join(cli.dir(), rpcCookieFile)
+}
+
+func (cli *CommandLine) serverToken() (string, error) {
+	if cli.rpcToken != "" {
+		return cli.rpcToken, nil
+	}
+
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(secret)
+
+	if err := os.WriteFile(cli.cookiePath(), []byte(token), 0600); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("RPC token written to %s\n", cli.cookiePath())
+
+	return token, nil
+}
+
+func (cli *CommandLine) clientToken() (string, error) {
+	if cli.rpcToken != "" {
+		return cli.rpcToken, nil
+	}
+
+	token, err := os.ReadFile(cli.cookiePath())
+
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no rpc token given with -rpctoken and no cookie file at %s", cli.cookiePath())
+	}
+
+	return strings.TrimSpace(string(token)), err
+}
+
+func (cli *CommandLine) serve(rpcAddress, explorerAddress string) error {
+	chain, err := cli.openChain()
+
+	if err != nil {
+		return err
+	}
+
+	defer chain.ShutdownDB()
+
+	if rpcAddress != "" {
+		token, err := cli.serverToken()
+
+		if err != nil {
+			return err
+		}
+
+		if cli.rpcToken == "" {
+			defer os.Remove(cli.cookiePath())
+		}
+
+		server, err := rpc.NewServer(chain, cli.dir(), rpcAddress, token)
+
+		if err != nil {
+			return err
+		}
+
+		if err := server.Start(); err != nil {
+			return err
+		}
+
+		defer server.Close()
+
+		fmt.Printf("RPC server listening on %s\n", server.Address)
+	}
+
+	if explorerAddress != "" {
+		listener, err := net.Listen("tcp", explorerAddress)
+
+		if err != nil {
+			return err
+		}
+
+		server := &http.Server{Handler: explorer.NewHandler(chain), ReadHeaderTimeout: 10 * time.Second}
+
+		go server.Serve(listener)
+		defer server.Close()
+
+		fmt.Printf("Explorer listening on %s\n", listener.Addr())
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	return nil
+}
+
+func withRemotePassphrase(passphrase string, call func(passphrase string) error) error {
+	err := call(passphrase)
+
+	if passphrase != "" || !(errors.Is(err, wallet.ErrWrongPassphrase) || errors.Is(err, wallet.ErrEmptyPassphrase)) {
+		return err
+	}
+
+	if passphrase, err = readPassphrase("Wallet passphrase: "); err != nil {
+		return err
+	}
+
+	return call(passphrase)
+}
+
+func (cli *CommandLine) remoteGetBalance(address string) error {
+	balance, err := cli.client.GetBalance(address)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Balance of %s: %d\n", balance.Address, balance.Balance)
+	fmt.Printf("Immature: %d\n", balance.Immature)
+
+	return nil
+}
+
+func (cli *CommandLine) remoteCreateWallet(passphrase string, withMnemonic bool) error {
+	var result *rpc.CreateWalletResult
+
+	err := withRemotePassphrase(passphrase, func(passphrase string) error {
+		var err error
+
+		result, err = cli.client.CreateWallet(passphrase, withMnemonic)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if result.Mnemonic != "" {
+		fmt.Printf("Mnemonic: %s\n", result.Mnemonic)
+		fmt.Println("Write these words down, they are the only backup of the wallet seed")
+	}
+
+	fmt.Printf("New address: %s\n", result.Address)
+
+	return nil
+}
+
+func (cli *CommandLine) remoteListAddresses() error {
+	addresses, err := cli.client.ListAddresses()
+
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		fmt.Println(address)
+	}
+
+	return nil
+}
+
+func (cli *CommandLine) remoteSend(from, to string, amount, fee, lockTime int, passphrase string) error {
+	var ID string
+
+	err := withRemotePassphrase(passphrase, func(passphrase string) error {
+		var err error
+
+		ID, err = cli.client.SendTransaction(rpc.SendTransactionParams{
+			From:       from,
+			To:         to,
+			Amount:     amount,
+			Fee:        fee,
+			LockTime:   lockTime,
+			Passphrase: passphrase,
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction %s added to the mempool of the server\n", ID)
+
+	return nil
+}
+
+func (cli *CommandLine) remoteMine(address string, maxBlockSize int) error {
+	hashes, err := cli.client.Generate(rpc.GenerateParams{Address: address, Count: 1, BlockSize: maxBlockSize})
+
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		fmt.Printf("Mined block %s on the server\n", hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,374 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/rpc"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errBadRequest = errors.New("bad request")
+
+type Explorer struct {
+	Chain  *blockchain.Blockchain
+	Logger *log.Logger
+}
+
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type BlockSummary struct {
+	Hash      string `json:"hash"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	PrevHash  string `json:"prevhash"`
+	Bits      int    `json:"bits"`
+	TxCount   int    `json:"txcount"`
+}
+
+type TransactionResult struct {
+	rpc.TransactionResult
+	BlockHash string `json:"blockhash,omitempty"`
+	Height    int    `json:"height"`
+}
+
+type UnspentOutputResult struct {
+	TxID     string `json:"txid"`
+	Index    int    `json:"index"`
+	Value    int    `json:"value"`
+	Height   int    `json:"height"`
+	Coinbase bool   `json:"coinbase"`
+}
+
+type HistoryEntry struct {
+	TxID           string   `json:"txid"`
+	BlockHash      string   `json:"blockhash"`
+	Height         int      `json:"height"`
+	Position       int      `json:"position"`
+	Timestamp      int64    `json:"timestamp"`
+	Coinbase       bool     `json:"coinbase"`
+	Received       int      `json:"received"`
+	Sent           int      `json:"sent"`
+	Counterparties []string `json:"counterparties"`
+}
+
+func NewHandler(chain *blockchain.Blockchain) http.Handler {
+	e := &Explorer{Chain: chain, Logger: log.Default()}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /blocks", e.handle(e.listBlocks))
+	mux.HandleFunc("GET /blocks/{hash}", e.handle(e.getBlock))
+	mux.HandleFunc("GET /blocks/height/{height}", e.handle(e.getBlockByHeight))
+	mux.HandleFunc("GET /tx/{id}", e.handle(e.getTransaction))
+	mux.HandleFunc("GET /address/{address}/utxos", e.handle(e.listUnspentOutputs))
+	mux.HandleFunc("GET /address/{address}/history", e.handle(e.listHistory))
+
+	return mux
+}
+
+func (e *Explorer) handle(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := fn(r)
+		status := http.StatusOK
+
+		switch {
+		case err == nil:
+		case errors.Is(err, errBadRequest), errors.Is(err, wallet.ErrInvalidAddress):
+			status = http.StatusBadRequest
+		case errors.Is(err, blockchain.ErrBlockNotFound), errors.Is(err, blockchain.ErrTxNotFound):
+			status = http.StatusNotFound
+		default:
+			status = http.StatusInternalServerError
+			e.Logger.Printf("explorer %s: %v", r.URL.Path, err)
+		}
+
+		if err != nil {
+			result = map[string]string{"error": err.Error()}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			e.Logger.Printf("writing explorer response: %v", err)
+		}
+	}
+}
+
+func pageSize(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+
+	if value == "" {
+		return defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", errBadRequest, maxPageSize)
+	}
+
+	return limit, nil
+}
+
+func decodeHex(name, value string) ([]byte, error) {
+	decoded, err := hex.DecodeString(value)
+
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("%w: invalid %s %q", errBadRequest, name, value)
+	}
+
+	return decoded, nil
+}
+
+func (e *Explorer) listBlocks(r *http.Request) (interface{}, error) {
+	limit, err := pageSize(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hash := e.Chain.Tip()
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		if hash, err = decodeHex("cursor", cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	summaries := []BlockSummary{}
+
+	for len(hash) > 0 && len(summaries) < limit {
+		block, err := e.Chain.GetBlock(hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, BlockSummary{
+			Hash:      hex.EncodeToString(block.Hash),
+			Height:    block.Height,
+			Timestamp: block.Timestamp,
+			PrevHash:  hex.EncodeToString(block.PrevHash),
+			Bits:      block.Bits,
+			TxCount:   len(block.Transactions),
+		})
+		hash = block.PrevHash
+	}
+
+	return Page{summaries, hex.EncodeToString(hash)}, nil
+}
+
+func (e *Explorer) getBlock(r *http.Request) (interface{}, error) {
+	hash, err := decodeHex("block hash", r.PathValue("hash"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := e.Chain.GetBlock(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rpc.NewBlockResult(e.Chain.Params, block), nil
+}
+
+func (e *Explorer) getBlockByHeight(r *http.Request) (interface{}, error) {
+	height, err := strconv.Atoi(r.PathValue("height"))
+
+	if err != nil || height < 0 {
+		return nil, fmt.Errorf("%w: invalid height %q", errBadRequest, r.PathValue("height"))
+	}
+
+	block, err := e.Chain.GetBlockByHeight(height)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rpc.NewBlockResult(e.Chain.Params, block), nil
+}
+
+func (e *Explorer) getTransaction(r *http.Request) (interface{}, error) {
+	ID, err := decodeHex("transaction ID", r.PathValue("id"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, position, err := e.Chain.LocateTransaction(ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := rpc.NewTransactionResult(e.Chain.Params, block.Transactions[position])
+
+	return TransactionResult{result, hex.EncodeToString(block.Hash), block.Height}, nil
+}
+
+func (e *Explorer) lockingScript(r *http.Request) ([]byte, error) {
+	address := r.PathValue("address")
+	lockingScript, err := blockchain.LockingScript(e.Chain.Params, address)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, address)
+	}
+
+	return lockingScript, nil
+}
+
+func outpointCursor(txID []byte, index int) string {
+	return hex.EncodeToString(binary.BigEndian.AppendUint32(bytes.Clone(txID), uint32(index)))
+}
+
+func (e *Explorer) listUnspentOutputs(r *http.Request) (interface{}, error) {
+	limit, err := pageSize(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lockingScript, err := e.lockingScript(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	after := r.URL.Query().Get("cursor")
+
+	if after != "" {
+		if _, err := decodeHex("cursor", after); err != nil {
+			return nil, err
+		}
+	}
+
+	unspent, err := e.Chain.FindUnspentOutputs(lockingScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page := Page{}
+	results := []UnspentOutputResult{}
+	last := ""
+
+	for _, out := range unspent {
+		cursor := outpointCursor(out.TxID, out.Index)
+
+		if cursor <= strings.ToLower(after) {
+			continue
+		}
+
+		if len(results) == limit {
+			page.NextCursor = last
+
+			break
+		}
+
+		results = append(results, UnspentOutputResult{hex.EncodeToString(out.TxID), out.Index, out.Value, out.Height, out.Coinbase})
+		last = cursor
+	}
+
+	page.Items = results
+
+	return page, nil
+}
+
+func (e *Explorer) listHistory(r *http.Request) (interface{}, error) {
+	limit, err := pageSize(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lockingScript, err := e.lockingScript(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	beforeHeight, beforePosition := -1, 0
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		if beforeHeight, beforePosition, err = parseHistoryCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	history, err := e.Chain.GetAddressTransactions(lockingScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page := Page{}
+	entries := []HistoryEntry{}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+
+		if beforeHeight >= 0 && (entry.Height > beforeHeight || (entry.Height == beforeHeight && entry.Position >= beforePosition)) {
+			continue
+		}
+
+		if len(entries) == limit {
+			last := entries[limit-1]
+			page.NextCursor = fmt.Sprintf("%d-%d", last.Height, last.Position)
+
+			break
+		}
+
+		counterparties := entry.Counterparties
+
+		if counterparties == nil {
+			counterparties = []string{}
+		}
+
+		entries = append(entries, HistoryEntry{
+			TxID:           hex.EncodeToString(entry.TxID),
+			BlockHash:      hex.EncodeToString(entry.BlockHash),
+			Height:         entry.Height,
+			Position:       entry.Position,
+			Timestamp:      entry.Timestamp,
+			Coinbase:       entry.Coinbase,
+			Received:       entry.Received,
+			Sent:           entry.Sent,
+			Counterparties: counterparties,
+		})
+	}
+
+	page.Items = entries
+
+	return page, nil
+}
+
+func parseHistoryCursor(cursor string) (int, int, error) {
+	encodedHeight, encodedPosition, ok := strings.Cut(cursor, "-")
+	height, err := strconv.Atoi(encodedHeight)
+	position, positionErr := strconv.Atoi(encodedPosition)
+
+	if !ok || err != nil || positionErr != nil || height < 0 || position < 0 {
+		return 0, 0, fmt.Errorf("%w: invalid cursor %q", errBadRequest, cursor)
+	}
+
+	return height, position, nil
+}
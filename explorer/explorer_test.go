@@ -0,0 +1,338 @@
+package explorer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type testWallet struct {
+	*wallet.Wallet
+	address string
+}
+
+func newTestWallet(t *testing.T) testWallet {
+	t.Helper()
+
+	w, err := wallet.MakeWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := wallet.EncodeAddress(wallet.PublicKeyHash(w.PublicKey), blockchain.RegTestParams.AddressVersion)
+
+	return testWallet{w, string(address)}
+}
+
+func mine(t *testing.T, chain *blockchain.Blockchain, miner testWallet, txs ...*blockchain.Transaction) *blockchain.Block {
+	t.Helper()
+
+	height, err := chain.GetBestHeight()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbase, err := blockchain.CoinbaseTx(chain.Params, miner.address, "", height+1, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := chain.MineBlock(context.Background(), append([]*blockchain.Transaction{coinbase}, txs...))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return block
+}
+
+type fixture struct {
+	chain   *blockchain.Blockchain
+	server  *httptest.Server
+	alice   testWallet
+	bob     testWallet
+	payment *blockchain.Transaction
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	f := &fixture{alice: newTestWallet(t), bob: newTestWallet(t)}
+	chain, err := blockchain.InitBlockchain(t.TempDir(), &blockchain.RegTestParams, f.alice.address, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { chain.ShutdownDB() })
+	f.chain = chain
+
+	for i := 0; i < chain.Params.CoinbaseMaturity; i++ {
+		mine(t, chain, f.alice)
+	}
+
+	from, err := blockchain.LockingScript(chain.Params, f.alice.address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		tx, err := blockchain.NewUnsignedTransaction(from, f.bob.address, 10, 1, 0, chain, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := chain.SignTransaction(tx, *f.alice.PrivateKey); err != nil {
+			t.Fatal(err)
+		}
+
+		tx.ID = tx.Hash()
+		mine(t, chain, f.alice, tx)
+		f.payment = tx
+	}
+
+	f.server = httptest.NewServer(NewHandler(chain))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fixture) get(t *testing.T, path string, status int, result interface{}) {
+	t.Helper()
+
+	response, err := http.Get(f.server.URL + path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != status {
+		t.Fatalf("GET %s replied %s, want %d", path, response.Status, status)
+	}
+
+	if result == nil {
+		return
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		t.Fatalf("decoding GET %s: %v", path, err)
+	}
+}
+
+func collect[T any](t *testing.T, f *fixture, path string, limit int) []T {
+	t.Helper()
+
+	var all []T
+
+	cursor := ""
+
+	for {
+		var page struct {
+			Items      []T    `json:"items"`
+			NextCursor string `json:"next_cursor"`
+		}
+
+		f.get(t, fmt.Sprintf("%s?limit=%d&cursor=%s", path, limit, url.QueryEscape(cursor)), http.StatusOK, &page)
+
+		if len(page.Items) > limit {
+			t.Fatalf("GET %s returned %d items, more than the limit of %d", path, len(page.Items), limit)
+		}
+
+		all = append(all, page.Items...)
+
+		if page.NextCursor == "" {
+			return all
+		}
+
+		cursor = page.NextCursor
+	}
+}
+
+func TestBlockRoutes(t *testing.T) {
+	f := newFixture(t)
+	tip, err := f.chain.GetBlock(f.chain.Tip())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var byHash, byHeight struct {
+		Hash   string `json:"hash"`
+		Height int    `json:"height"`
+	}
+
+	f.get(t, "/blocks/"+hex.EncodeToString(tip.Hash), http.StatusOK, &byHash)
+	f.get(t, fmt.Sprintf("/blocks/height/%d", tip.Height), http.StatusOK, &byHeight)
+
+	if byHash != byHeight || byHash.Hash != hex.EncodeToString(tip.Hash) || byHash.Height != tip.Height {
+		t.Fatalf("got blocks %+v and %+v, want the tip %x at height %d", byHash, byHeight, tip.Hash, tip.Height)
+	}
+
+	blocks := collect[BlockSummary](t, f, "/blocks", 4)
+
+	if len(blocks) != tip.Height+1 {
+		t.Fatalf("paging returned %d blocks, want %d", len(blocks), tip.Height+1)
+	}
+
+	for i, block := range blocks {
+		if block.Height != tip.Height-i {
+			t.Fatalf("block %d of the listing is at height %d, want %d", i, block.Height, tip.Height-i)
+		}
+	}
+}
+
+func TestTransactionRoute(t *testing.T) {
+	f := newFixture(t)
+	var result TransactionResult
+
+	f.get(t, "/tx/"+hex.EncodeToString(f.payment.ID), http.StatusOK, &result)
+
+	if result.ID != hex.EncodeToString(f.payment.ID) || result.BlockHash != hex.EncodeToString(f.chain.Tip()) {
+		t.Fatalf("got transaction %s in block %s, want %x in the tip", result.ID, result.BlockHash, f.payment.ID)
+	}
+
+	if len(result.Outputs) == 0 || result.Outputs[0].Address != f.bob.address {
+		t.Fatalf("first output does not pay %s: %+v", f.bob.address, result.Outputs)
+	}
+}
+
+func TestAddressRoutes(t *testing.T) {
+	f := newFixture(t)
+	utxos := collect[UnspentOutputResult](t, f, "/address/"+f.bob.address+"/utxos", 2)
+
+	if len(utxos) != 3 {
+		t.Fatalf("bob has %d unspent outputs, want 3", len(utxos))
+	}
+
+	seen := map[string]bool{}
+
+	for _, out := range utxos {
+		key := fmt.Sprintf("%s:%d", out.TxID, out.Index)
+
+		if seen[key] || out.Value != 10 {
+			t.Fatalf("unexpected unspent output %+v", out)
+		}
+
+		seen[key] = true
+	}
+
+	history := collect[HistoryEntry](t, f, "/address/"+f.alice.address+"/history", 5)
+	height, err := f.chain.GetBestHeight()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := height + 1 + 3; len(history) != want {
+		t.Fatalf("alice has %d history entries, want %d coinbases and payments", len(history), want)
+	}
+
+	for i := 1; i < len(history); i++ {
+		previous, entry := history[i-1], history[i]
+
+		if entry.Height > previous.Height || (entry.Height == previous.Height && entry.Position >= previous.Position) {
+			t.Fatalf("history is not newest first at entry %d: %+v after %+v", i, entry, previous)
+		}
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	f := newFixture(t)
+	missing := hex.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/blocks?limit=0", http.StatusBadRequest},
+		{"/blocks?limit=1000", http.StatusBadRequest},
+		{"/blocks?cursor=xyz", http.StatusBadRequest},
+		{"/blocks/xyz", http.StatusBadRequest},
+		{"/blocks/height/-1", http.StatusBadRequest},
+		{"/tx/xyz", http.StatusBadRequest},
+		{"/address/nonsense/utxos", http.StatusBadRequest},
+		{"/address/" + f.bob.address + "/utxos?cursor=xyz", http.StatusBadRequest},
+		{"/address/" + f.bob.address + "/history?cursor=1", http.StatusBadRequest},
+		{"/blocks/" + missing, http.StatusNotFound},
+		{"/blocks/height/1000", http.StatusNotFound},
+		{"/tx/" + missing, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		var body map[string]string
+
+		f.get(t, test.path, test.status, &body)
+
+		if body["error"] == "" {
+			t.Errorf("GET %s replied without an error message", test.path)
+		}
+	}
+}
+
+func TestListBlocksWhileMining(t *testing.T) {
+	f := newFixture(t)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 20; i++ {
+			height, err := f.chain.GetBestHeight()
+
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			coinbase, err := blockchain.CoinbaseTx(f.chain.Params, f.alice.address, "", height+1, 0)
+
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			if _, err := f.chain.MineBlock(context.Background(), []*blockchain.Transaction{coinbase}); err != nil {
+				t.Error(err)
+
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			height, err := f.chain.GetBestHeight()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if blocks := collect[BlockSummary](t, f, "/blocks", maxPageSize); len(blocks) != height+1 {
+				t.Fatalf("listed %d blocks, want %d", len(blocks), height+1)
+			}
+
+			return
+		default:
+			var page Page
+
+			f.get(t, "/blocks?limit=5", http.StatusOK, &page)
+		}
+	}
+}
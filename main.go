@@ -6,8 +6,6 @@ import (
 )
 
 func main() {
-	defer os.Exit(0)
-
 	cmd := cli.CommandLine{}
-	cmd.Run()
+	os.Exit(cmd.Run())
 }
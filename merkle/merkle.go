@@ -0,0 +1,153 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+	rootPrefix = 0x02
+)
+
+type Tree struct {
+	Count  int
+	Levels [][][]byte
+}
+
+type Proof struct {
+	Index    int
+	Count    int
+	Siblings [][]byte
+}
+
+func hashLeaf(data []byte) []byte {
+	hash := sha256.Sum256(append([]byte{leafPrefix}, data...))
+
+	return hash[:]
+}
+
+func hashNodes(left, right []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{nodePrefix}, left...), right...))
+
+	return hash[:]
+}
+
+func hashRoot(count int, top []byte) []byte {
+	data := binary.BigEndian.AppendUint32([]byte{rootPrefix}, uint32(count))
+	hash := sha256.Sum256(append(data, top...))
+
+	return hash[:]
+}
+
+func NewTree(data [][]byte) *Tree {
+	var leaves [][]byte
+
+	for _, datum := range data {
+		leaves = append(leaves, hashLeaf(datum))
+	}
+
+	if len(leaves) == 0 {
+		leaves = append(leaves, hashLeaf(nil))
+	}
+
+	tree := &Tree{len(data), [][][]byte{leaves}}
+
+	for level := leaves; len(level) > 1; {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+
+		var next [][]byte
+
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashNodes(level[i], level[i+1]))
+		}
+
+		tree.Levels = append(tree.Levels, next)
+		level = next
+	}
+
+	return tree
+}
+
+func (tree *Tree) Root() []byte {
+	return hashRoot(tree.Count, tree.Levels[len(tree.Levels)-1][0])
+}
+
+func (tree *Tree) Proof(index int) (*Proof, error) {
+	if index < 0 || index >= tree.Count {
+		return nil, errors.New("leaf index out of range")
+	}
+
+	proof := &Proof{Index: index, Count: tree.Count}
+
+	for _, level := range tree.Levels[:len(tree.Levels)-1] {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof.Siblings = append(proof.Siblings, level[sibling])
+		}
+
+		index /= 2
+	}
+
+	return proof, nil
+}
+
+func (proof *Proof) Verify(root, data []byte) bool {
+	if proof.Index < 0 || proof.Index >= proof.Count {
+		return false
+	}
+
+	hash := hashLeaf(data)
+	index, size, siblings := proof.Index, proof.Count, proof.Siblings
+
+	for ; size > 1; size = (size + 1) / 2 {
+		if index == size-1 && size%2 != 0 {
+			hash = hashNodes(hash, hash)
+		} else {
+			if len(siblings) == 0 || bytes.Equal(siblings[0], hash) {
+				return false
+			}
+
+			if index%2 == 0 {
+				hash = hashNodes(hash, siblings[0])
+			} else {
+				hash = hashNodes(siblings[0], hash)
+			}
+
+			siblings = siblings[1:]
+		}
+
+		index /= 2
+	}
+
+	return len(siblings) == 0 && bytes.Equal(hashRoot(proof.Count, hash), root)
+}
+
+func (proof *Proof) Serialize() []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(proof.Index))
+	data = binary.BigEndian.AppendUint32(data, uint32(proof.Count))
+
+	for _, sibling := range proof.Siblings {
+		data = append(data, sibling...)
+	}
+
+	return data
+}
+
+func DeserializeProof(data []byte) (*Proof, error) {
+	if len(data) < 8 || (len(data)-8)%sha256.Size != 0 {
+		return nil, errors.New("malformed merkle proof")
+	}
+
+	proof := &Proof{Index: int(binary.BigEndian.Uint32(data[:4])), Count: int(binary.BigEndian.Uint32(data[4:8]))}
+
+	for i := 8; i < len(data); i += sha256.Size {
+		proof.Siblings = append(proof.Siblings, data[i:i+sha256.Size])
+	}
+
+	return proof, nil
+}
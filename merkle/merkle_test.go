@@ -0,0 +1,117 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func testLeaves(count int) [][]byte {
+	var leaves [][]byte
+
+	for i := 0; i < count; i++ {
+		hash := sha256.Sum256([]byte(fmt.Sprint(i)))
+		leaves = append(leaves, hash[:])
+	}
+
+	return leaves
+}
+
+func TestProofsVerify(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		leaves := testLeaves(count)
+		tree := NewTree(leaves)
+
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(i)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := DeserializeProof(proof.Serialize())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !decoded.Verify(tree.Root(), leaf) {
+				t.Fatalf("proof for leaf %d of %d does not verify", i, count)
+			}
+
+			if decoded.Verify(tree.Root(), leaves[(i+1)%count]) && count > 1 {
+				t.Fatalf("proof for leaf %d of %d verifies another leaf", i, count)
+			}
+		}
+	}
+}
+
+func TestInternalNodeIsNotALeaf(t *testing.T) {
+	tree := NewTree(testLeaves(4))
+	node := tree.Levels[1][0]
+	proof := &Proof{0, 4, [][]byte{tree.Levels[1][1]}}
+
+	if proof.Verify(tree.Root(), node) {
+		t.Fatal("an internal node was accepted as a leaf")
+	}
+
+	children := append(append([]byte{}, tree.Levels[0][0]...), tree.Levels[0][1]...)
+
+	if bytes.Equal(hashLeaf(children), node) {
+		t.Fatal("a leaf made of two child hashes hashes to their parent")
+	}
+}
+
+func TestDuplicatedLastLeafChangesTheRoot(t *testing.T) {
+	for count := 1; count <= 9; count += 2 {
+		leaves := testLeaves(count)
+		padded := append(leaves, leaves[count-1])
+
+		if bytes.Equal(NewTree(leaves).Root(), NewTree(padded).Root()) {
+			t.Fatalf("%d leaves and the same leaves with the last one repeated share a root", count)
+		}
+	}
+}
+
+func TestForgedProofsAreRejected(t *testing.T) {
+	leaves := testLeaves(3)
+	tree := NewTree(leaves)
+	proof, err := tree.Proof(2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tree.Proof(3); err == nil {
+		t.Fatal("built a proof for the duplicated leaf")
+	}
+
+	paddedProof, err := NewTree(append(leaves, leaves[2])).Proof(3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]*Proof{
+		"duplicated index":          {3, 3, proof.Siblings},
+		"duplicated leaf proof":     paddedProof,
+		"padded leaf count":         {2, 4, append([][]byte{leaves[2]}, proof.Siblings...)},
+		"negative index":            {-1, 3, proof.Siblings},
+		"extra sibling":             {2, 3, append(proof.Siblings, proof.Siblings[0])},
+		"missing sibling":           {2, 3, nil},
+		"sibling equal to the node": {0, 3, [][]byte{hashLeaf(leaves[0]), proof.Siblings[0]}},
+	}
+
+	for name, forged := range tests {
+		decoded, err := DeserializeProof(forged.Serialize())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Verify(tree.Root(), leaves[2]) || decoded.Verify(tree.Root(), leaves[0]) {
+			t.Errorf("%s: forged proof verifies", name)
+		}
+	}
+}
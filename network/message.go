@@ -0,0 +1,77 @@
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+const (
+	protocolVersion = 1
+	maxInvItems     = 500
+)
+
+const (
+	cmdVersion   = "version"
+	cmdVerack    = "verack"
+	cmdGetBlocks = "getblocks"
+	cmdInv       = "inv"
+	cmdGetData   = "getdata"
+	cmdBlock     = "block"
+	cmdTx        = "tx"
+)
+
+const (
+	invBlock = "block"
+	invTx    = "tx"
+)
+
+type Message struct {
+	Command string
+	Payload []byte
+}
+
+type Version struct {
+	Version    int
+	Network    string
+	BestHeight int
+	AddrFrom   string
+}
+
+type Verack struct {
+}
+
+type GetBlocks struct {
+	Locator [][]byte
+}
+
+type Inv struct {
+	Type  string
+	Items [][]byte
+}
+
+type GetData struct {
+	Type string
+	ID   []byte
+}
+
+type BlockMsg struct {
+	Block []byte
+}
+
+type TxMsg struct {
+	Transaction []byte
+}
+
+func encodePayload(payload interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(payload); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func decodePayload(data []byte, payload interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(payload)
+}
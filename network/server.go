@@ -0,0 +1,535 @@
+package network
+
+import (
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+const sendQueueSize = 1024
+
+type Server struct {
+	Address string
+	Chain   *blockchain.Blockchain
+	Mempool *blockchain.Mempool
+	Logger  *log.Logger
+
+	listener net.Listener
+	mu       sync.Mutex
+	peers    map[*peer]bool
+	wg       sync.WaitGroup
+	closed   bool
+}
+
+type peer struct {
+	conn       net.Conn
+	address    string
+	bestHeight int
+	requested  map[string]bool
+	out        chan Message
+	closed     bool
+}
+
+func NewServer(chain *blockchain.Blockchain, address string) (*Server, error) {
+	pool, err := blockchain.NewMempool(chain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		Address: address,
+		Chain:   chain,
+		Mempool: pool,
+		Logger:  log.Default(),
+		peers:   make(map[*peer]bool),
+	}, nil
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.Address)
+
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	s.Address = listener.Addr().String()
+	s.wg.Add(1)
+
+	go s.acceptLoop()
+
+	return nil
+}
+
+func (s *Server) Close() error {
+	var err error
+
+	s.mu.Lock()
+	s.closed = true
+
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+
+	for p := range s.peers {
+		p.close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) Connect(address string) error {
+	conn, err := net.Dial("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	p := s.addPeer(conn, address)
+
+	if p == nil {
+		return errors.New("server is closed")
+	}
+
+	return s.sendVersion(p)
+}
+
+func (s *Server) Peers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var addresses []string
+
+	for p := range s.peers {
+		addresses = append(addresses, p.address)
+	}
+
+	return addresses
+}
+
+func (s *Server) BroadcastBlock(block *blockchain.Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.relay(nil, Inv{invBlock, [][]byte{block.Hash}})
+}
+
+func (s *Server) SubmitTransaction(tx *blockchain.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.acceptTransaction(nil, tx)
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		s.addPeer(conn, conn.RemoteAddr().String())
+	}
+}
+
+func (s *Server) addPeer(conn net.Conn, address string) *peer {
+	p := &peer{
+		conn:      conn,
+		address:   address,
+		requested: make(map[string]bool),
+		out:       make(chan Message, sendQueueSize),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		conn.Close()
+
+		return nil
+	}
+
+	s.peers[p] = true
+	s.wg.Add(2)
+
+	go s.writeLoop(p)
+	go s.readLoop(p)
+
+	return p
+}
+
+func (s *Server) removePeer(p *peer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.peers, p)
+	p.close()
+}
+
+func (p *peer) close() {
+	if p.closed {
+		return
+	}
+
+	p.closed = true
+	close(p.out)
+	p.conn.Close()
+}
+
+func (s *Server) writeLoop(p *peer) {
+	defer s.wg.Done()
+
+	encoder := gob.NewEncoder(p.conn)
+
+	for msg := range p.out {
+		if err := encoder.Encode(msg); err != nil {
+			s.Logger.Printf("sending %s to %s: %v", msg.Command, p.address, err)
+			p.conn.Close()
+		}
+	}
+}
+
+func (s *Server) readLoop(p *peer) {
+	defer s.wg.Done()
+	defer s.removePeer(p)
+
+	decoder := gob.NewDecoder(p.conn)
+
+	for {
+		var msg Message
+
+		if err := decoder.Decode(&msg); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.Logger.Printf("reading from %s: %v", p.address, err)
+			}
+
+			return
+		}
+
+		if err := s.handle(p, msg); err != nil {
+			s.Logger.Printf("handling %s from %s: %v", msg.Command, p.address, err)
+
+			return
+		}
+	}
+}
+
+func (s *Server) send(p *peer, command string, payload interface{}) {
+	if p.closed {
+		return
+	}
+
+	data, err := encodePayload(payload)
+
+	if err != nil {
+		s.Logger.Printf("encoding %s: %v", command, err)
+
+		return
+	}
+
+	select {
+	case p.out <- Message{command, data}:
+	default:
+		s.Logger.Printf("send queue to %s is full, disconnecting", p.address)
+		p.conn.Close()
+	}
+}
+
+func (s *Server) relay(from *peer, inv Inv) {
+	for p := range s.peers {
+		if p != from {
+			s.send(p, cmdInv, inv)
+		}
+	}
+}
+
+func (s *Server) sendVersion(p *peer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bestHeight, err := s.Chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	s.send(p, cmdVersion, Version{protocolVersion, s.Chain.Params.Name, bestHeight, s.Address})
+
+	return nil
+}
+
+func (s *Server) handle(p *peer, msg Message) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed %s message: %v", msg.Command, r)
+		}
+	}()
+
+	switch msg.Command {
+	case cmdVersion:
+		var payload Version
+
+		if err := decodePayload(msg.Payload, &payload); err != nil {
+			return err
+		}
+
+		return s.handleVersion(p, payload)
+	case cmdVerack:
+		return nil
+	case cmdGetBlocks:
+		var payload GetBlocks
+
+		if err := decodePayload(msg.Payload, &payload); err != nil {
+			return err
+		}
+
+		return s.handleGetBlocks(p, payload)
+	case cmdInv:
+		var payload Inv
+
+		if err := decodePayload(msg.Payload, &payload); err != nil {
+			return err
+		}
+
+		return s.handleInv(p, payload)
+	case cmdGetData:
+		var payload GetData
+
+		if err := decodePayload(msg.Payload, &payload); err != nil {
+			return err
+		}
+
+		return s.handleGetData(p, payload)
+	case cmdBlock:
+		var payload BlockMsg
+
+		if err := decodePayload(msg.Payload, &payload); err != nil {
+			return err
+		}
+
+		block, err := blockchain.Deserialize(payload.Block)
+
+		if err != nil {
+			return err
+		}
+
+		return s.handleBlock(p, block)
+	case cmdTx:
+		var payload TxMsg
+
+		if err := decodePayload(msg.Payload, &payload); err != nil {
+			return err
+		}
+
+		tx, err := blockchain.DeserializeTransaction(payload.Transaction)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.acceptTransaction(p, &tx); err != nil {
+			s.Logger.Printf("rejected transaction %x from %s: %v", tx.ID, p.address, err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", msg.Command)
+	}
+}
+
+func (s *Server) handleVersion(p *peer, payload Version) error {
+	if payload.Version != protocolVersion {
+		return fmt.Errorf("unsupported protocol version %d", payload.Version)
+	}
+
+	if payload.Network != s.Chain.Params.Name {
+		return fmt.Errorf("peer is on network %q", payload.Network)
+	}
+
+	if payload.AddrFrom != "" {
+		p.address = payload.AddrFrom
+	}
+
+	p.bestHeight = payload.BestHeight
+	bestHeight, err := s.Chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	s.send(p, cmdVerack, Verack{})
+
+	if p.bestHeight > bestHeight {
+		return s.requestBlocks(p)
+	} else if p.bestHeight < bestHeight {
+		s.send(p, cmdVersion, Version{protocolVersion, s.Chain.Params.Name, bestHeight, s.Address})
+	}
+
+	return nil
+}
+
+func (s *Server) handleGetBlocks(p *peer, payload GetBlocks) error {
+	hashes, err := s.Chain.GetBlockHashesAfter(payload.Locator, maxInvItems)
+
+	if err != nil {
+		return err
+	}
+
+	if len(hashes) > 0 {
+		s.send(p, cmdInv, Inv{invBlock, hashes})
+	}
+
+	return nil
+}
+
+func (s *Server) handleInv(p *peer, payload Inv) error {
+	for _, item := range payload.Items {
+		switch payload.Type {
+		case invBlock:
+			exists, err := s.Chain.HasBlock(item)
+
+			if err != nil {
+				return err
+			}
+
+			if exists || p.requested[hex.EncodeToString(item)] {
+				continue
+			}
+
+			p.requested[hex.EncodeToString(item)] = true
+		case invTx:
+			if _, ok := s.Mempool.Get(item); ok {
+				continue
+			}
+		default:
+			return fmt.Errorf("unknown inventory type %q", payload.Type)
+		}
+
+		s.send(p, cmdGetData, GetData{payload.Type, item})
+	}
+
+	return nil
+}
+
+func (s *Server) handleGetData(p *peer, payload GetData) error {
+	switch payload.Type {
+	case invBlock:
+		block, err := s.Chain.GetBlock(payload.ID)
+
+		if errors.Is(err, blockchain.ErrBlockNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		s.send(p, cmdBlock, BlockMsg{block.Serialize()})
+	case invTx:
+		tx, ok := s.Mempool.Get(payload.ID)
+
+		if !ok {
+			return nil
+		}
+
+		s.send(p, cmdTx, TxMsg{tx.Serialize()})
+	default:
+		return fmt.Errorf("unknown inventory type %q", payload.Type)
+	}
+
+	return nil
+}
+
+func (s *Server) handleBlock(p *peer, block *blockchain.Block) error {
+	delete(p.requested, hex.EncodeToString(block.Hash))
+	p.bestHeight = max(p.bestHeight, block.Height)
+
+	update, err := s.Chain.ImportBlock(block)
+
+	switch {
+	case errors.Is(err, blockchain.ErrBlockExists):
+		return nil
+	case errors.Is(err, blockchain.ErrOrphanBlock):
+		return s.requestBlocks(p)
+	case err != nil:
+		s.Logger.Printf("rejected block %x from %s: %v", block.Hash, p.address, err)
+
+		return nil
+	}
+
+	if len(update.Connected) == 0 {
+		s.Logger.Printf("stored side chain block %x at height %d", block.Hash, block.Height)
+
+		return s.continueSync(p)
+	}
+
+	if err := s.Mempool.ApplyChainUpdate(update); err != nil {
+		return err
+	}
+
+	s.Logger.Printf("added block %x at height %d", block.Hash, block.Height)
+
+	if len(update.Disconnected) > 0 {
+		s.Logger.Printf("reorganized %d blocks away", len(update.Disconnected))
+	}
+
+	s.relay(p, Inv{invBlock, [][]byte{block.Hash}})
+
+	return s.continueSync(p)
+}
+
+func (s *Server) continueSync(p *peer) error {
+	bestHeight, err := s.Chain.GetBestHeight()
+
+	if err != nil {
+		return err
+	}
+
+	if len(p.requested) == 0 && p.bestHeight > bestHeight {
+		return s.requestBlocks(p)
+	}
+
+	return nil
+}
+
+func (s *Server) requestBlocks(p *peer) error {
+	locator, err := s.Chain.GetBlockLocator()
+
+	if err != nil {
+		return err
+	}
+
+	s.send(p, cmdGetBlocks, GetBlocks{locator})
+
+	return nil
+}
+
+func (s *Server) acceptTransaction(from *peer, tx *blockchain.Transaction) error {
+	if err := s.Mempool.Add(tx); err != nil {
+		if errors.Is(err, blockchain.ErrAlreadyInPool) {
+			return nil
+		}
+
+		return err
+	}
+
+	s.relay(from, Inv{invTx, [][]byte{tx.ID}})
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testWallet struct {
+	*wallet.Wallet
+	address string
+}
+
+func newTestWallet(t *testing.T) testWallet {
+	t.Helper()
+
+	w, err := wallet.MakeWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := wallet.EncodeAddress(wallet.PublicKeyHash(w.PublicKey), blockchain.RegTestParams.AddressVersion)
+
+	return testWallet{w, string(address)}
+}
+
+func copyDir(t *testing.T, from, to string) {
+	t.Helper()
+
+	err := filepath.WalkDir(from, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(to, rel), 0700)
+		}
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(to, rel), data, 0600)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestNetwork(t *testing.T, miner testWallet, nodes int) []*Server {
+	t.Helper()
+
+	genesisDir := t.TempDir()
+	chain, err := blockchain.InitBlockchain(genesisDir, &blockchain.RegTestParams, miner.address, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.ShutdownDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	var servers []*Server
+
+	for i := 0; i < nodes; i++ {
+		dir := t.TempDir()
+		copyDir(t, genesisDir, dir)
+
+		chain, err := blockchain.ContinueBlockchain(dir, &blockchain.RegTestParams)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		server, err := NewServer(chain, "localhost:0")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		server.Logger = log.New(io.Discard, "", 0)
+
+		if err := server.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() {
+			server.Close()
+			chain.ShutdownDB()
+		})
+
+		servers = append(servers, server)
+	}
+
+	return servers
+}
+
+func mine(t *testing.T, s *Server, miner testWallet) *blockchain.Block {
+	t.Helper()
+
+	s.mu.Lock()
+	block, err := s.Chain.MinePending(context.Background(), s.Mempool, miner.address, 0)
+	s.mu.Unlock()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return block
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func (s *Server) tip() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Chain.Tip()
+}
+
+func TestBlockSync(t *testing.T) {
+	miner := newTestWallet(t)
+	servers := newTestNetwork(t, miner, 3)
+	a, b, c := servers[0], servers[1], servers[2]
+
+	for i := 0; i < 5; i++ {
+		mine(t, a, miner)
+	}
+
+	if err := b.Connect(a.Address); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Connect(b.Address); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []*Server{b, c} {
+		waitFor(t, "the initial block download", func() bool { return bytes.Equal(s.tip(), a.tip()) })
+	}
+
+	block := mine(t, a, miner)
+	a.BroadcastBlock(block)
+
+	waitFor(t, "the new block to reach every node", func() bool {
+		return bytes.Equal(b.tip(), block.Hash) && bytes.Equal(c.tip(), block.Hash)
+	})
+}
+
+func TestTransactionRelay(t *testing.T) {
+	miner, bob := newTestWallet(t), newTestWallet(t)
+	servers := newTestNetwork(t, miner, 3)
+	a, b, c := servers[0], servers[1], servers[2]
+
+	for i := 0; i < a.Chain.Params.CoinbaseMaturity+1; i++ {
+		mine(t, a, miner)
+	}
+
+	if err := b.Connect(a.Address); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Connect(b.Address); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, "the initial block download", func() bool { return bytes.Equal(c.tip(), a.tip()) })
+
+	from, err := blockchain.LockingScript(c.Chain.Params, miner.address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	tx, err := blockchain.NewUnsignedTransaction(from, bob.address, 10, 1, 0, c.Chain, c.Mempool)
+
+	if err == nil {
+		err = c.Chain.SignTransaction(tx, *miner.PrivateKey)
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx.ID = tx.Hash()
+
+	if err := c.SubmitTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	inPool := func(s *Server) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		_, ok := s.Mempool.Get(tx.ID)
+
+		return ok
+	}
+
+	waitFor(t, "the transaction to reach the first node", func() bool { return inPool(a) && inPool(b) })
+
+	block := mine(t, a, miner)
+
+	if len(block.Transactions) != 2 {
+		t.Fatalf("mined block has %d transactions, want the coinbase and the relayed one", len(block.Transactions))
+	}
+
+	a.BroadcastBlock(block)
+
+	waitFor(t, "the block to confirm the transaction everywhere", func() bool {
+		return bytes.Equal(c.tip(), block.Hash) && !inPool(b) && !inPool(c)
+	})
+}
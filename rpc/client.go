@@ -0,0 +1,177 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Client struct {
+	URL        string
+	Token      string
+	HTTPClient *http.Client
+
+	nextID atomic.Int64
+}
+
+func NewClient(address, token string) *Client {
+	url := address
+
+	if !strings.Contains(url, "://") {
+		if strings.HasPrefix(url, ":") {
+			url = "localhost" + url
+		}
+
+		url = "http://" + url
+	}
+
+	return &Client{
+		URL:        url,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+func (c *Client) Call(method string, params, result interface{}) error {
+	request := Request{JSONRPC: jsonRPCVersion, Method: method}
+	request.ID = json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+
+	if params != nil {
+		encoded, err := json.Marshal(params)
+
+		if err != nil {
+			return err
+		}
+
+		request.Params = encoded
+	}
+
+	body, err := json.Marshal(request)
+
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Authorization", "Bearer "+c.Token)
+	httpResponse, err := c.HTTPClient.Do(httpRequest)
+
+	if err != nil {
+		return err
+	}
+
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc server replied %s", httpResponse.Status)
+	}
+
+	var response Response
+
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return fmt.Errorf("decoding rpc response: %w", err)
+	}
+
+	if response.Error != nil {
+		return response.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+func (c *Client) GetBalance(address string) (*BalanceResult, error) {
+	var result BalanceResult
+
+	if err := c.Call("getbalance", GetBalanceParams{address}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetBlock(hash string) (*BlockResult, error) {
+	var result BlockResult
+
+	if err := c.Call("getblock", GetBlockParams{hash}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetBlockHash(height int) (string, error) {
+	var result string
+
+	err := c.Call("getblockhash", GetBlockHashParams{height}, &result)
+
+	return result, err
+}
+
+func (c *Client) GetTransaction(ID string) (*TransactionResult, error) {
+	var result TransactionResult
+
+	if err := c.Call("gettransaction", GetTransactionParams{ID}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) SendTransaction(params SendTransactionParams) (string, error) {
+	var result string
+
+	err := c.Call("sendtransaction", params, &result)
+
+	return result, err
+}
+
+func (c *Client) CreateWallet(passphrase string, mnemonic bool) (*CreateWalletResult, error) {
+	var result CreateWalletResult
+
+	if err := c.Call("createwallet", CreateWalletParams{passphrase, mnemonic}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) ListAddresses() ([]string, error) {
+	var result []string
+
+	err := c.Call("listaddresses", nil, &result)
+
+	return result, err
+}
+
+func (c *Client) GetChainInfo() (*ChainInfoResult, error) {
+	var result ChainInfoResult
+
+	if err := c.Call("getchaininfo", nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) Generate(params GenerateParams) ([]string, error) {
+	var result []string
+
+	err := c.Call("generate", params, &result)
+
+	return result, err
+}
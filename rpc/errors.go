@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"errors"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+)
+
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+var (
+	errInvalidParams = errors.New("invalid params")
+	errNoToken       = errors.New("rpc server requires an authentication token")
+)
+
+var errorCodes = []struct {
+	code int
+	err  error
+}{
+	{CodeInvalidParams, errInvalidParams},
+	{-32001, blockchain.ErrBlockNotFound},
+	{-32002, blockchain.ErrTxNotFound},
+	{-32003, blockchain.ErrInsufficientFunds},
+	{-32004, blockchain.ErrInvalidSignature},
+	{-32005, blockchain.ErrAlreadyInPool},
+	{-32006, blockchain.ErrConflictingSpend},
+	{-32007, blockchain.ErrNonFinal},
+	{-32008, blockchain.ErrImmatureCoinbase},
+	{-32009, blockchain.ErrInvalidTransaction},
+	{-32010, wallet.ErrUnknownWallet},
+	{-32011, wallet.ErrInvalidAddress},
+	{-32012, wallet.ErrWalletLocked},
+	{-32013, wallet.ErrWrongPassphrase},
+	{-32014, wallet.ErrEmptyPassphrase},
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	for _, known := range errorCodes {
+		if known.code == e.Code {
+			return known.err
+		}
+	}
+
+	return nil
+}
+
+func newError(err error) *Error {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return &Error{known.code, err.Error()}
+		}
+	}
+
+	return &Error{CodeServerError, err.Error()}
+}
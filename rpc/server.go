@@ -0,0 +1,542 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxRequestSize   = 1 << 20
+	maxGenerateCount = 1000
+)
+
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var methods = map[string]handler{
+	"getbalance":      (*Server).getBalance,
+	"getblock":        (*Server).getBlock,
+	"getblockhash":    (*Server).getBlockHash,
+	"gettransaction":  (*Server).getTransaction,
+	"sendtransaction": (*Server).sendTransaction,
+	"createwallet":    (*Server).createWallet,
+	"listaddresses":   (*Server).listAddresses,
+	"getchaininfo":    (*Server).getChainInfo,
+	"generate":        (*Server).generate,
+}
+
+type Server struct {
+	Address   string
+	Chain     *blockchain.Blockchain
+	Mempool   *blockchain.Mempool
+	WalletDir string
+	Token     string
+	Logger    *log.Logger
+
+	mu         sync.Mutex
+	httpServer *http.Server
+	done       chan struct{}
+}
+
+func NewServer(chain *blockchain.Blockchain, walletDir, address, token string) (*Server, error) {
+	pool, err := blockchain.NewMempool(chain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		Address:   address,
+		Chain:     chain,
+		Mempool:   pool,
+		WalletDir: walletDir,
+		Token:     token,
+		Logger:    log.Default(),
+	}, nil
+}
+
+func localAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+
+	if err != nil || host != "" {
+		return address
+	}
+
+	return net.JoinHostPort("localhost", port)
+}
+
+func (s *Server) Start() error {
+	if s.Token == "" {
+		return errNoToken
+	}
+
+	listener, err := net.Listen("tcp", localAddress(s.Address))
+
+	if err != nil {
+		return err
+	}
+
+	s.Address = listener.Addr().String()
+	s.httpServer = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.Logger.Printf("rpc server: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+
+	err := s.httpServer.Close()
+	<-s.done
+
+	return err
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	if !ok {
+		_, token, ok = r.BasicAuth()
+	}
+
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="rpc"`)
+		http.Error(w, "missing or wrong rpc token", http.StatusUnauthorized)
+
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "JSON-RPC requests must use POST", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var response interface{}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		response = s.handleBatch(trimmed)
+	} else if single := s.handleMessage(trimmed); single != nil {
+		response = single
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.Logger.Printf("writing rpc response: %v", err)
+	}
+}
+
+func (s *Server) handleBatch(body []byte) interface{} {
+	var messages []json.RawMessage
+
+	if err := json.Unmarshal(body, &messages); err != nil {
+		return errorResponse(nil, &Error{CodeParseError, err.Error()})
+	}
+
+	if len(messages) == 0 {
+		return errorResponse(nil, &Error{CodeInvalidRequest, "empty batch"})
+	}
+
+	var responses []*Response
+
+	for _, message := range messages {
+		if response := s.handleMessage(message); response != nil {
+			responses = append(responses, response)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+
+	return responses
+}
+
+func (s *Server) handleMessage(message []byte) *Response {
+	var request Request
+
+	if err := json.Unmarshal(message, &request); err != nil {
+		if json.Valid(message) {
+			return errorResponse(nil, &Error{CodeInvalidRequest, err.Error()})
+		}
+
+		return errorResponse(nil, &Error{CodeParseError, err.Error()})
+	}
+
+	if request.JSONRPC != jsonRPCVersion || request.Method == "" {
+		return errorResponse(request.ID, &Error{CodeInvalidRequest, "invalid JSON-RPC 2.0 request"})
+	}
+
+	result, rpcErr := s.call(request.Method, request.Params)
+
+	if request.ID == nil {
+		return nil
+	}
+
+	if rpcErr != nil {
+		return errorResponse(request.ID, rpcErr)
+	}
+
+	encoded, err := json.Marshal(result)
+
+	if err != nil {
+		return errorResponse(request.ID, &Error{CodeInternalError, err.Error()})
+	}
+
+	return &Response{JSONRPC: jsonRPCVersion, Result: encoded, ID: request.ID}
+}
+
+func (s *Server) call(method string, params json.RawMessage) (interface{}, *Error) {
+	handle, ok := methods[method]
+
+	if !ok {
+		return nil, &Error{CodeMethodNotFound, fmt.Sprintf("method %q not found", method)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := handle(s, params)
+
+	if err != nil {
+		return nil, newError(err)
+	}
+
+	return result, nil
+}
+
+func errorResponse(ID json.RawMessage, rpcErr *Error) *Response {
+	if ID == nil {
+		ID = json.RawMessage("null")
+	}
+
+	return &Response{JSONRPC: jsonRPCVersion, Error: rpcErr, ID: ID}
+}
+
+func decodeParams(params json.RawMessage, target interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidParams, err)
+	}
+
+	return nil
+}
+
+func decodeHex(name, value string) ([]byte, error) {
+	decoded, err := hex.DecodeString(value)
+
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("%w: %s must be a hex string", errInvalidParams, name)
+	}
+
+	return decoded, nil
+}
+
+func (s *Server) openWallets() (*wallet.Wallets, error) {
+	return wallet.CreateWallets(s.WalletDir, s.Chain.Params.AddressVersion)
+}
+
+func (s *Server) getBalance(raw json.RawMessage) (interface{}, error) {
+	var params GetBalanceParams
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	lockingScript, err := blockchain.LockingScript(s.Chain.Params, params.Address)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, params.Address)
+	}
+
+	spendable, immature, err := s.Chain.GetBalance(lockingScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return BalanceResult{params.Address, spendable, immature}, nil
+}
+
+func (s *Server) getBlock(raw json.RawMessage) (interface{}, error) {
+	var params GetBlockParams
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	hash, err := decodeHex("hash", params.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := s.Chain.GetBlock(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlockResult(s.Chain.Params, block), nil
+}
+
+func (s *Server) getBlockHash(raw json.RawMessage) (interface{}, error) {
+	var params GetBlockHashParams
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	hash, err := s.Chain.GetBlockHash(params.Height)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(hash), nil
+}
+
+func (s *Server) getTransaction(raw json.RawMessage) (interface{}, error) {
+	var params GetTransactionParams
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	ID, err := decodeHex("id", params.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tx, ok := s.Mempool.Get(ID); ok {
+		return NewTransactionResult(s.Chain.Params, tx), nil
+	}
+
+	tx, err := s.Chain.FindTransaction(ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTransactionResult(s.Chain.Params, &tx), nil
+}
+
+func (s *Server) sendTransaction(raw json.RawMessage) (interface{}, error) {
+	var params SendTransactionParams
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.buildTransaction(params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Mempool.Add(tx); err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
+
+func (s *Server) buildTransaction(params SendTransactionParams) (*blockchain.Transaction, error) {
+	if params.Tx != "" {
+		data, err := decodeHex("tx", params.Tx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := blockchain.DeserializeTransaction(data)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidParams, err)
+		}
+
+		return &tx, nil
+	}
+
+	if params.From == "" || params.To == "" {
+		return nil, fmt.Errorf("%w: either tx or from and to are required", errInvalidParams)
+	}
+
+	if _, err := blockchain.LockingScript(s.Chain.Params, params.To); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, params.To)
+	}
+
+	wallets, err := s.openWallets()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return blockchain.NewTransaction(wallets, params.From, params.To, params.Amount, params.Fee, params.LockTime, params.Passphrase, s.Chain, s.Mempool)
+}
+
+func (s *Server) createWallet(raw json.RawMessage) (interface{}, error) {
+	var params CreateWalletParams
+	var result CreateWalletResult
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	wallets, err := s.openWallets()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wallets.UnlockOrEncrypt(params.Passphrase); err != nil {
+		return nil, err
+	}
+
+	if params.Mnemonic {
+		if result.Mnemonic, err = wallet.NewMnemonic(); err != nil {
+			return nil, err
+		}
+
+		if err := wallets.SetMnemonic(result.Mnemonic); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Address, err = wallets.AddWallet(); err != nil {
+		return nil, err
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Server) listAddresses(raw json.RawMessage) (interface{}, error) {
+	if err := decodeParams(raw, &struct{}{}); err != nil {
+		return nil, err
+	}
+
+	wallets, err := s.openWallets()
+
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := wallets.GetAllAddresses()
+
+	if addresses == nil {
+		addresses = []string{}
+	}
+
+	return addresses, nil
+}
+
+func (s *Server) getChainInfo(raw json.RawMessage) (interface{}, error) {
+	if err := decodeParams(raw, &struct{}{}); err != nil {
+		return nil, err
+	}
+
+	tip, err := s.Chain.GetBlock(s.Chain.Tip())
+
+	if err != nil {
+		return nil, err
+	}
+
+	work, err := s.Chain.GetChainWork(tip.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ChainInfoResult{
+		Network:       s.Chain.Params.Name,
+		Height:        tip.Height,
+		BestBlockHash: hex.EncodeToString(tip.Hash),
+		Bits:          tip.Bits,
+		ChainWork:     work.Text(16),
+		MempoolSize:   s.Mempool.Len(),
+	}, nil
+}
+
+func (s *Server) generate(raw json.RawMessage) (interface{}, error) {
+	var params GenerateParams
+
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Count < 1 || params.Count > maxGenerateCount {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", errInvalidParams, maxGenerateCount)
+	}
+
+	if _, err := blockchain.LockingScript(s.Chain.Params, params.Address); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, params.Address)
+	}
+
+	hashes := []string{}
+
+	for i := 0; i < params.Count; i++ {
+		block, err := s.Chain.MinePending(context.Background(), s.Mempool, params.Address, params.BlockSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, hex.EncodeToString(block.Hash))
+	}
+
+	return hashes, nil
+}
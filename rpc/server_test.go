@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"errors"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+	"github.com/e-aleixandre/go-blockchain/wallet"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testToken = "secret-token"
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	w, err := wallet.MakeWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	address := string(w.Address(blockchain.RegTestParams.AddressVersion))
+	chain, err := blockchain.InitBlockchain(dir, &blockchain.RegTestParams, address, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { chain.ShutdownDB() })
+
+	server, err := NewServer(chain, dir, "localhost:0", testToken)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.Logger = log.New(io.Discard, "", 0)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	return server, httpServer
+}
+
+func post(t *testing.T, url string, authorize func(r *http.Request)) int {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"jsonrpc":"2.0","method":"getchaininfo","id":1}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorize(request)
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestAuthentication(t *testing.T) {
+	_, httpServer := newTestServer(t)
+
+	tests := map[string]struct {
+		authorize func(r *http.Request)
+		status    int
+	}{
+		"no token":     {func(r *http.Request) {}, http.StatusUnauthorized},
+		"wrong token":  {func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		"wrong scheme": {func(r *http.Request) { r.Header.Set("Authorization", testToken) }, http.StatusUnauthorized},
+		"bearer token": {func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+testToken) }, http.StatusOK},
+		"basic auth":   {func(r *http.Request) { r.SetBasicAuth("", testToken) }, http.StatusOK},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if status := post(t, httpServer.URL, test.authorize); status != test.status {
+				t.Fatalf("got status %d, want %d", status, test.status)
+			}
+		})
+	}
+
+	if _, err := NewClient(httpServer.URL, "wrong").GetChainInfo(); err == nil {
+		t.Fatal("client with a wrong token was served")
+	}
+}
+
+func TestStartRequiresToken(t *testing.T) {
+	server, _ := newTestServer(t)
+	server.Token = ""
+
+	if err := server.Start(); !errors.Is(err, errNoToken) {
+		t.Fatalf("got %v, want %v", err, errNoToken)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	_, httpServer := newTestServer(t)
+	client := NewClient(httpServer.URL, testToken)
+
+	tests := []struct {
+		method string
+		params interface{}
+		code   int
+	}{
+		{"nosuchmethod", nil, CodeMethodNotFound},
+		{"generate", GenerateParams{Count: 0}, CodeInvalidParams},
+		{"generate", GenerateParams{Count: maxGenerateCount + 1}, CodeInvalidParams},
+		{"getblock", map[string]string{"unknown": "field"}, CodeInvalidParams},
+		{"getblock", GetBlockParams{"zz"}, CodeInvalidParams},
+		{"getblockhash", GetBlockHashParams{1000}, -32001},
+	}
+
+	for _, test := range tests {
+		var rpcErr *Error
+
+		if err := client.Call(test.method, test.params, nil); !errors.As(err, &rpcErr) || rpcErr.Code != test.code {
+			t.Errorf("%s %v gave %v, want code %d", test.method, test.params, err, test.code)
+		}
+	}
+}
+
+func TestSendGenerateAndGetTransaction(t *testing.T) {
+	server, httpServer := newTestServer(t)
+	client := NewClient(httpServer.URL, testToken)
+
+	alice, err := client.CreateWallet("pass", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := client.CreateWallet("pass", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Generate(GenerateParams{Address: alice.Address, Count: server.Chain.Params.CoinbaseMaturity + 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	ID, err := client.SendTransaction(SendTransactionParams{From: alice.Address, To: bob.Address, Amount: 30, Fee: 2, Passphrase: "pass"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := client.GetChainInfo(); err != nil || info.MempoolSize != 1 {
+		t.Fatalf("mempool does not hold the sent transaction: %+v (%v)", info, err)
+	}
+
+	hashes, err := client.Generate(GenerateParams{Address: alice.Address, Count: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := client.GetBlock(hashes[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(block.Transactions) != 2 || block.Transactions[1].ID != ID {
+		t.Fatalf("generated block does not confirm %s", ID)
+	}
+
+	tx, err := client.GetTransaction(ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.ID != ID || tx.Outputs[0].Address != bob.Address || tx.Outputs[0].Value != 30 {
+		t.Fatalf("got transaction %+v", tx)
+	}
+
+	balance, err := client.GetBalance(bob.Address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance.Balance != 30 {
+		t.Fatalf("bob has %d, want 30", balance.Balance)
+	}
+}
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/e-aleixandre/go-blockchain/blockchain"
+)
+
+const jsonRPCVersion = "2.0"
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type GetBalanceParams struct {
+	Address string `json:"address"`
+}
+
+type GetBlockParams struct {
+	Hash string `json:"hash"`
+}
+
+type GetBlockHashParams struct {
+	Height int `json:"height"`
+}
+
+type GetTransactionParams struct {
+	ID string `json:"id"`
+}
+
+type SendTransactionParams struct {
+	Tx         string `json:"tx,omitempty"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	Amount     int    `json:"amount,omitempty"`
+	Fee        int    `json:"fee,omitempty"`
+	LockTime   int    `json:"locktime,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+type CreateWalletParams struct {
+	Passphrase string `json:"passphrase,omitempty"`
+	Mnemonic   bool   `json:"mnemonic,omitempty"`
+}
+
+type GenerateParams struct {
+	Address   string `json:"address"`
+	Count     int    `json:"count"`
+	BlockSize int    `json:"blocksize,omitempty"`
+}
+
+type BalanceResult struct {
+	Address  string `json:"address"`
+	Balance  int    `json:"balance"`
+	Immature int    `json:"immature"`
+}
+
+type CreateWalletResult struct {
+	Address  string `json:"address"`
+	Mnemonic string `json:"mnemonic,omitempty"`
+}
+
+type ChainInfoResult struct {
+	Network       string `json:"network"`
+	Height        int    `json:"height"`
+	BestBlockHash string `json:"bestblockhash"`
+	Bits          int    `json:"bits"`
+	ChainWork     string `json:"chainwork"`
+	MempoolSize   int    `json:"mempoolsize"`
+}
+
+type InputResult struct {
+	TxID     string `json:"txid,omitempty"`
+	Out      int    `json:"out"`
+	Coinbase string `json:"coinbase,omitempty"`
+	Script   string `json:"script,omitempty"`
+	Sequence uint32 `json:"sequence"`
+}
+
+type OutputResult struct {
+	Value   int    `json:"value"`
+	Script  string `json:"script"`
+	Address string `json:"address,omitempty"`
+}
+
+type TransactionResult struct {
+	ID       string         `json:"id"`
+	Size     int            `json:"size"`
+	LockTime int            `json:"locktime"`
+	Inputs   []InputResult  `json:"inputs"`
+	Outputs  []OutputResult `json:"outputs"`
+}
+
+type BlockResult struct {
+	Hash         string              `json:"hash"`
+	Height       int                 `json:"height"`
+	Version      int                 `json:"version"`
+	Timestamp    int64               `json:"timestamp"`
+	PrevHash     string              `json:"prevhash"`
+	MerkleRoot   string              `json:"merkleroot"`
+	Bits         int                 `json:"bits"`
+	Nonce        int                 `json:"nonce"`
+	Transactions []TransactionResult `json:"transactions"`
+}
+
+func NewTransactionResult(params *blockchain.Params, tx *blockchain.Transaction) TransactionResult {
+	result := TransactionResult{
+		ID:       hex.EncodeToString(tx.ID),
+		Size:     tx.Size(),
+		LockTime: tx.LockTime,
+		Inputs:   []InputResult{},
+		Outputs:  []OutputResult{},
+	}
+
+	for _, in := range tx.Inputs {
+		input := InputResult{Out: in.Out, Sequence: in.Sequence}
+
+		if tx.IsCoinbase() {
+			input.Coinbase = hex.EncodeToString(in.Script)
+		} else {
+			input.TxID = hex.EncodeToString(in.ID)
+			input.Script = hex.EncodeToString(in.Script)
+		}
+
+		result.Inputs = append(result.Inputs, input)
+	}
+
+	for _, out := range tx.Outputs {
+		address, _ := blockchain.ExtractAddress(params, out.Script)
+		result.Outputs = append(result.Outputs, OutputResult{out.Value, hex.EncodeToString(out.Script), address})
+	}
+
+	return result
+}
+
+func NewBlockResult(params *blockchain.Params, block *blockchain.Block) BlockResult {
+	result := BlockResult{
+		Hash:         hex.EncodeToString(block.Hash),
+		Height:       block.Height,
+		Version:      block.Version,
+		Timestamp:    block.Timestamp,
+		PrevHash:     hex.EncodeToString(block.PrevHash),
+		MerkleRoot:   hex.EncodeToString(block.MerkleRoot),
+		Bits:         block.Bits,
+		Nonce:        block.Nonce,
+		Transactions: []TransactionResult{},
+	}
+
+	for _, tx := range block.Transactions {
+		result.Transactions = append(result.Transactions, NewTransactionResult(params, tx))
+	}
+
+	return result
+}
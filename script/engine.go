@@ -0,0 +1,349 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"golang.org/x/crypto/ripemd160"
+	"slices"
+)
+
+const (
+	MaxStackSize = 1000
+	MaxOps       = 201
+)
+
+type Checker interface {
+	CheckSignature(signature, pubKey, scriptCode []byte) bool
+	CheckLockTime(lockTime int64) bool
+	CheckSequence(sequence int64) bool
+}
+
+type engine struct {
+	stack   [][]byte
+	checker Checker
+	script  []byte
+	ops     int
+}
+
+func Execute(unlocking, locking []byte, checker Checker) error {
+	if !IsPushOnly(unlocking) {
+		return ErrPushOnly
+	}
+
+	e := &engine{checker: checker}
+
+	if err := e.run(unlocking); err != nil {
+		return err
+	}
+
+	unlocked := slices.Clone(e.stack)
+
+	if err := e.run(locking); err != nil {
+		return err
+	}
+
+	if err := e.succeeded(); err != nil {
+		return err
+	}
+
+	if _, ok := ExtractScriptHash(locking); !ok {
+		return nil
+	}
+
+	if len(unlocked) == 0 {
+		return fmt.Errorf("%w: missing redeem script", ErrStackUnderflow)
+	}
+
+	redeemScript := unlocked[len(unlocked)-1]
+	e.stack = unlocked[:len(unlocked)-1]
+
+	if err := e.run(redeemScript); err != nil {
+		return err
+	}
+
+	return e.succeeded()
+}
+
+func (e *engine) succeeded() error {
+	if len(e.stack) == 0 || !asBool(e.stack[len(e.stack)-1]) {
+		return ErrScriptFailed
+	}
+
+	return nil
+}
+
+func (e *engine) run(script []byte) error {
+	instructions, err := Parse(script)
+
+	if err != nil {
+		return err
+	}
+
+	e.script = script
+	e.ops = 0
+
+	for _, instruction := range instructions {
+		if err := e.step(instruction); err != nil {
+			return fmt.Errorf("%s: %w", opcodeName(instruction.Op), err)
+		}
+
+		if len(e.stack) > MaxStackSize {
+			return fmt.Errorf("%w: stack exceeds %d items", ErrInvalidScript, MaxStackSize)
+		}
+	}
+
+	return nil
+}
+
+func (e *engine) step(instruction Instruction) error {
+	if isPush(instruction.Op) {
+		data := pushValue(instruction)
+
+		if len(data) > MaxElementSize {
+			return fmt.Errorf("%w: push of %d bytes", ErrInvalidScript, len(data))
+		}
+
+		e.push(data)
+
+		return nil
+	}
+
+	if e.ops++; e.ops > MaxOps {
+		return fmt.Errorf("%w: more than %d operations", ErrInvalidScript, MaxOps)
+	}
+
+	switch instruction.Op {
+	case OP_NOP:
+		return nil
+	case OP_RETURN:
+		return ErrEarlyReturn
+	case OP_VERIFY:
+		return e.verify()
+	case OP_DROP:
+		_, err := e.pop()
+
+		return err
+	case OP_DUP:
+		top, err := e.peek()
+
+		if err != nil {
+			return err
+		}
+
+		e.push(top)
+
+		return nil
+	case OP_EQUAL, OP_EQUALVERIFY:
+		b, err := e.pop()
+
+		if err != nil {
+			return err
+		}
+
+		a, err := e.pop()
+
+		if err != nil {
+			return err
+		}
+
+		e.pushBool(bytes.Equal(a, b))
+
+		if instruction.Op == OP_EQUALVERIFY {
+			return e.verify()
+		}
+
+		return nil
+	case OP_RIPEMD160, OP_SHA256, OP_HASH160, OP_HASH256:
+		data, err := e.pop()
+
+		if err != nil {
+			return err
+		}
+
+		e.push(hash(instruction.Op, data))
+
+		return nil
+	case OP_CHECKSIG, OP_CHECKSIGVERIFY:
+		pubKey, err := e.pop()
+
+		if err != nil {
+			return err
+		}
+
+		signature, err := e.pop()
+
+		if err != nil {
+			return err
+		}
+
+		e.pushBool(e.checker.CheckSignature(signature, pubKey, e.script))
+
+		if instruction.Op == OP_CHECKSIGVERIFY {
+			return e.verify()
+		}
+
+		return nil
+	case OP_CHECKLOCKTIMEVERIFY, OP_CHECKSEQUENCEVERIFY:
+		top, err := e.peek()
+
+		if err != nil {
+			return err
+		}
+
+		value, err := decodeInt(top)
+
+		if err != nil {
+			return err
+		}
+
+		if instruction.Op == OP_CHECKLOCKTIMEVERIFY && !e.checker.CheckLockTime(int64(value)) {
+			return ErrLockTime
+		}
+
+		if instruction.Op == OP_CHECKSEQUENCEVERIFY && !e.checker.CheckSequence(int64(value)) {
+			return ErrLockTime
+		}
+
+		return nil
+	case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+		valid, err := e.checkMultiSig()
+
+		if err != nil {
+			return err
+		}
+
+		e.pushBool(valid)
+
+		if instruction.Op == OP_CHECKMULTISIGVERIFY {
+			return e.verify()
+		}
+
+		return nil
+	default:
+		return ErrUnknownOpcode
+	}
+}
+
+func (e *engine) checkMultiSig() (bool, error) {
+	pubKeys, err := e.popItems(0, MaxMultiSigKeys)
+
+	if err != nil {
+		return false, err
+	}
+
+	if e.ops += len(pubKeys); e.ops > MaxOps {
+		return false, fmt.Errorf("%w: more than %d operations", ErrInvalidScript, MaxOps)
+	}
+
+	signatures, err := e.popItems(0, len(pubKeys))
+
+	if err != nil {
+		return false, err
+	}
+
+	for len(signatures) > 0 {
+		if len(pubKeys) < len(signatures) {
+			return false, nil
+		}
+
+		if e.checker.CheckSignature(signatures[0], pubKeys[0], e.script) {
+			signatures = signatures[1:]
+		}
+
+		pubKeys = pubKeys[1:]
+	}
+
+	return true, nil
+}
+
+func (e *engine) popItems(minCount, maxCount int) ([][]byte, error) {
+	top, err := e.pop()
+
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := decodeInt(top)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if count < minCount || count > maxCount {
+		return nil, fmt.Errorf("%w: item count %d out of range", ErrInvalidScript, count)
+	}
+
+	if len(e.stack) < count {
+		return nil, ErrStackUnderflow
+	}
+
+	items := slices.Clone(e.stack[len(e.stack)-count:])
+	e.stack = e.stack[:len(e.stack)-count]
+
+	return items, nil
+}
+
+func (e *engine) push(data []byte) {
+	e.stack = append(e.stack, data)
+}
+
+func (e *engine) pushBool(value bool) {
+	if value {
+		e.push([]byte{1})
+	} else {
+		e.push(nil)
+	}
+}
+
+func (e *engine) peek() ([]byte, error) {
+	if len(e.stack) == 0 {
+		return nil, ErrStackUnderflow
+	}
+
+	return e.stack[len(e.stack)-1], nil
+}
+
+func (e *engine) pop() ([]byte, error) {
+	top, err := e.peek()
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.stack = e.stack[:len(e.stack)-1]
+
+	return top, nil
+}
+
+func (e *engine) verify() error {
+	top, err := e.pop()
+
+	if err != nil {
+		return err
+	}
+
+	if !asBool(top) {
+		return ErrVerifyFailed
+	}
+
+	return nil
+}
+
+func hash(op byte, data []byte) []byte {
+	switch op {
+	case OP_RIPEMD160:
+		hasher := ripemd160.New()
+		hasher.Write(data)
+
+		return hasher.Sum(nil)
+	case OP_SHA256:
+		sum := sha256.Sum256(data)
+
+		return sum[:]
+	case OP_HASH160:
+		return hash(OP_RIPEMD160, hash(OP_SHA256, data))
+	default:
+		return hash(OP_SHA256, hash(OP_SHA256, data))
+	}
+}
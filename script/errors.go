@@ -0,0 +1,14 @@
+package script
+
+import "errors"
+
+var (
+	ErrInvalidScript  = errors.New("invalid script")
+	ErrScriptFailed   = errors.New("script evaluated to false")
+	ErrStackUnderflow = errors.New("not enough items on the stack")
+	ErrVerifyFailed   = errors.New("verify operation failed")
+	ErrEarlyReturn    = errors.New("script executed OP_RETURN")
+	ErrUnknownOpcode  = errors.New("unknown opcode")
+	ErrPushOnly       = errors.New("unlocking script must only push data")
+	ErrLockTime       = errors.New("lock time requirement not satisfied")
+)
@@ -0,0 +1,348 @@
+package script
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	MaxScriptSize   = 10000
+	MaxElementSize  = 520
+	MaxNullDataSize = 80
+	MaxMultiSigKeys = 16
+	pubKeyHashSize  = 20
+	scriptHashSize  = 20
+)
+
+type Instruction struct {
+	Op   byte
+	Data []byte
+}
+
+func Parse(script []byte) ([]Instruction, error) {
+	var instructions []Instruction
+
+	if len(script) > MaxScriptSize {
+		return nil, fmt.Errorf("%w: script is %d bytes long", ErrInvalidScript, len(script))
+	}
+
+	for i := 0; i < len(script); {
+		op := script[i]
+		i++
+
+		var length int
+
+		switch {
+		case op > OP_0 && op < OP_PUSHDATA1:
+			length = int(op)
+		case op == OP_PUSHDATA1:
+			if i+1 > len(script) {
+				return nil, fmt.Errorf("%w: truncated OP_PUSHDATA1", ErrInvalidScript)
+			}
+
+			length = int(script[i])
+			i++
+		case op == OP_PUSHDATA2:
+			if i+2 > len(script) {
+				return nil, fmt.Errorf("%w: truncated OP_PUSHDATA2", ErrInvalidScript)
+			}
+
+			length = int(binary.LittleEndian.Uint16(script[i:]))
+			i += 2
+		default:
+			instructions = append(instructions, Instruction{op, nil})
+
+			continue
+		}
+
+		if i+length > len(script) {
+			return nil, fmt.Errorf("%w: push of %d bytes past the end of the script", ErrInvalidScript, length)
+		}
+
+		instructions = append(instructions, Instruction{op, script[i : i+length]})
+		i += length
+	}
+
+	return instructions, nil
+}
+
+func AddOp(script []byte, op byte) []byte {
+	return append(script, op)
+}
+
+func AddData(script, data []byte) []byte {
+	switch {
+	case len(data) == 0:
+		return append(script, OP_0)
+	case len(data) < OP_PUSHDATA1:
+		script = append(script, byte(len(data)))
+	case len(data) <= 0xff:
+		script = append(script, OP_PUSHDATA1, byte(len(data)))
+	default:
+		script = append(script, OP_PUSHDATA2)
+		script = binary.LittleEndian.AppendUint16(script, uint16(len(data)))
+	}
+
+	return append(script, data...)
+}
+
+func AddInt(script []byte, n int) []byte {
+	if n == 0 {
+		return append(script, OP_0)
+	}
+
+	if n >= 1 && n <= 16 {
+		return append(script, byte(OP_1+n-1))
+	}
+
+	return AddData(script, encodeInt(n))
+}
+
+func PayToPubKeyHash(pubKeyHash []byte) []byte {
+	script := AddOp(nil, OP_DUP)
+	script = AddOp(script, OP_HASH160)
+	script = AddData(script, pubKeyHash)
+	script = AddOp(script, OP_EQUALVERIFY)
+
+	return AddOp(script, OP_CHECKSIG)
+}
+
+func ExtractPubKeyHash(script []byte) ([]byte, bool) {
+	if len(script) != pubKeyHashSize+5 {
+		return nil, false
+	}
+
+	if script[0] != OP_DUP || script[1] != OP_HASH160 || script[2] != pubKeyHashSize ||
+		script[pubKeyHashSize+3] != OP_EQUALVERIFY || script[pubKeyHashSize+4] != OP_CHECKSIG {
+		return nil, false
+	}
+
+	return script[3 : pubKeyHashSize+3], true
+}
+
+func PayToScriptHash(scriptHash []byte) []byte {
+	script := AddOp(nil, OP_HASH160)
+	script = AddData(script, scriptHash)
+
+	return AddOp(script, OP_EQUAL)
+}
+
+func ExtractScriptHash(script []byte) ([]byte, bool) {
+	if len(script) != scriptHashSize+3 {
+		return nil, false
+	}
+
+	if script[0] != OP_HASH160 || script[1] != scriptHashSize || script[scriptHashSize+2] != OP_EQUAL {
+		return nil, false
+	}
+
+	return script[2 : scriptHashSize+2], true
+}
+
+func MultiSig(required int, pubKeys [][]byte) ([]byte, error) {
+	if len(pubKeys) == 0 || len(pubKeys) > MaxMultiSigKeys {
+		return nil, fmt.Errorf("%w: multisig needs between 1 and %d keys", ErrInvalidScript, MaxMultiSigKeys)
+	}
+
+	if required < 1 || required > len(pubKeys) {
+		return nil, fmt.Errorf("%w: cannot require %d of %d signatures", ErrInvalidScript, required, len(pubKeys))
+	}
+
+	script := AddInt(nil, required)
+
+	for _, pubKey := range pubKeys {
+		script = AddData(script, pubKey)
+	}
+
+	script = AddInt(script, len(pubKeys))
+
+	return AddOp(script, OP_CHECKMULTISIG), nil
+}
+
+func ExtractMultiSig(script []byte) (int, [][]byte, bool) {
+	instructions, err := Parse(script)
+
+	if err != nil || len(instructions) < 4 || instructions[len(instructions)-1].Op != OP_CHECKMULTISIG {
+		return 0, nil, false
+	}
+
+	first, last := instructions[0], instructions[len(instructions)-2]
+
+	if !isSmallInt(first.Op) || !isSmallInt(last.Op) {
+		return 0, nil, false
+	}
+
+	var pubKeys [][]byte
+
+	for _, instruction := range instructions[1 : len(instructions)-2] {
+		if instruction.Op == OP_0 || instruction.Op > OP_PUSHDATA2 {
+			return 0, nil, false
+		}
+
+		pubKeys = append(pubKeys, instruction.Data)
+	}
+
+	required := smallIntValue(first.Op)
+
+	if smallIntValue(last.Op) != len(pubKeys) || required > len(pubKeys) {
+		return 0, nil, false
+	}
+
+	return required, pubKeys, true
+}
+
+func Hash160(data []byte) []byte {
+	return hash(OP_HASH160, data)
+}
+
+func SignatureScript(signature, pubKey []byte) []byte {
+	return AddData(AddData(nil, signature), pubKey)
+}
+
+func NullData(data []byte) ([]byte, error) {
+	if len(data) > MaxNullDataSize {
+		return nil, fmt.Errorf("%w: null data is limited to %d bytes", ErrInvalidScript, MaxNullDataSize)
+	}
+
+	return AddData(AddOp(nil, OP_RETURN), data), nil
+}
+
+func IsUnspendable(script []byte) bool {
+	return (len(script) > 0 && script[0] == OP_RETURN) || len(script) > MaxScriptSize
+}
+
+func IsPushOnly(script []byte) bool {
+	instructions, err := Parse(script)
+
+	if err != nil {
+		return false
+	}
+
+	for _, instruction := range instructions {
+		if !isPush(instruction.Op) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func PushedData(script []byte) ([][]byte, error) {
+	var data [][]byte
+
+	instructions, err := Parse(script)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instruction := range instructions {
+		if !isPush(instruction.Op) {
+			return nil, fmt.Errorf("%w: %s", ErrPushOnly, opcodeName(instruction.Op))
+		}
+
+		data = append(data, pushValue(instruction))
+	}
+
+	return data, nil
+}
+
+func pushValue(instruction Instruction) []byte {
+	if isSmallInt(instruction.Op) {
+		return []byte{byte(smallIntValue(instruction.Op))}
+	}
+
+	return instruction.Data
+}
+
+func opcodeName(op byte) string {
+	if name, ok := opcodeNames[op]; ok {
+		return name
+	}
+
+	if isSmallInt(op) {
+		return fmt.Sprintf("OP_%d", smallIntValue(op))
+	}
+
+	return fmt.Sprintf("OP_UNKNOWN_%#02x", op)
+}
+
+func Disassemble(script []byte) string {
+	instructions, err := Parse(script)
+
+	if err != nil {
+		return "[invalid] " + hex.EncodeToString(script)
+	}
+
+	var parts []string
+
+	for _, instruction := range instructions {
+		if instruction.Op > OP_0 && instruction.Op <= OP_PUSHDATA2 {
+			parts = append(parts, hex.EncodeToString(instruction.Data))
+		} else {
+			parts = append(parts, opcodeName(instruction.Op))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func encodeInt(n int) []byte {
+	var encoded []byte
+
+	negative := n < 0
+
+	if negative {
+		n = -n
+	}
+
+	for ; n > 0; n >>= 8 {
+		encoded = append(encoded, byte(n&0xff))
+	}
+
+	if len(encoded) > 0 && encoded[len(encoded)-1]&0x80 != 0 {
+		encoded = append(encoded, 0)
+	}
+
+	if negative && len(encoded) > 0 {
+		encoded[len(encoded)-1] |= 0x80
+	}
+
+	return encoded
+}
+
+func decodeInt(data []byte) (int, error) {
+	if len(data) > 8 {
+		return 0, fmt.Errorf("%w: number is %d bytes long", ErrInvalidScript, len(data))
+	}
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	n := 0
+
+	for i, b := range data {
+		n |= int(b) << (8 * i)
+	}
+
+	if data[len(data)-1]&0x80 != 0 {
+		n &^= 0x80 << (8 * (len(data) - 1))
+
+		return -n, nil
+	}
+
+	return n, nil
+}
+
+func asBool(data []byte) bool {
+	for i, b := range data {
+		if b != 0 {
+			return i != len(data)-1 || b != 0x80
+		}
+	}
+
+	return false
+}
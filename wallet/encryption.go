@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	sealedHeader = "encrypted-wallets-v1\n"
+)
+
+type sealedWallets struct {
+	Addresses  []string
+	Salt       []byte
+	N, R, P    int
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+func newGCM(passphrase []byte, sealed *sealedWallets) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, sealed.Salt, sealed.N, sealed.R, sealed.P, keyLength)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(passphrase, plaintext []byte, addresses []string) (*sealedWallets, error) {
+	sealed := &sealedWallets{
+		Addresses: addresses,
+		Salt:      make([]byte, saltLength),
+		N:         scryptN,
+		R:         scryptR,
+		P:         scryptP,
+	}
+
+	if _, err := rand.Read(sealed.Salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, sealed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sealed.Nonce = make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return nil, err
+	}
+
+	sealed.Ciphertext = gcm.Seal(nil, sealed.Nonce, plaintext, nil)
+
+	return sealed, nil
+}
+
+func (sealed *sealedWallets) open(passphrase []byte) ([]byte, error) {
+	gcm, err := newGCM(passphrase, sealed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return plaintext, nil
+}
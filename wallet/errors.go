@@ -0,0 +1,15 @@
+package wallet
+
+import "errors"
+
+var (
+	ErrUnknownWallet      = errors.New("wallet not found")
+	ErrInvalidAddress     = errors.New("invalid address")
+	ErrWalletLocked       = errors.New("wallet is locked, a passphrase is required")
+	ErrWrongPassphrase    = errors.New("wrong wallet passphrase")
+	ErrWalletEncrypted    = errors.New("wallet is already encrypted")
+	ErrWalletNotEncrypted = errors.New("wallet is not encrypted")
+	ErrEmptyPassphrase    = errors.New("passphrase cannot be empty")
+	ErrInvalidMnemonic    = errors.New("invalid mnemonic")
+	ErrSeedExists         = errors.New("wallet already has a seed")
+)
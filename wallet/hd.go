@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"github.com/tyler-smith/go-bip39"
+	"math/big"
+)
+
+const (
+	hardened      = uint32(1) << 31
+	entropyBits   = 128
+	scalarLength  = 32
+	masterHMACKey = "Nist256p1 seed"
+)
+
+type extendedKey struct {
+	Key       *big.Int
+	ChainCode []byte
+}
+
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+func MnemonicToSeed(mnemonic string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+
+	if err != nil {
+		return nil, ErrInvalidMnemonic
+	}
+
+	return seed, nil
+}
+
+func splitHMAC(key, data []byte) (*big.Int, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	return new(big.Int).SetBytes(sum[:32]), sum[32:]
+}
+
+func newMasterKey(seed []byte) *extendedKey {
+	n := elliptic.P256().Params().N
+	data := seed
+
+	for {
+		key, chainCode := splitHMAC([]byte(masterHMACKey), data)
+
+		if key.Sign() != 0 && key.Cmp(n) < 0 {
+			return &extendedKey{key, chainCode}
+		}
+
+		data = append(key.FillBytes(make([]byte, scalarLength)), chainCode...)
+	}
+}
+
+func (parent *extendedKey) child(index uint32) *extendedKey {
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	var data []byte
+
+	if index >= hardened {
+		data = append([]byte{0x00}, parent.Key.FillBytes(make([]byte, scalarLength))...)
+	} else {
+		x, y := curve.ScalarBaseMult(parent.Key.FillBytes(make([]byte, scalarLength)))
+		data = elliptic.MarshalCompressed(curve, x, y)
+	}
+
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	for {
+		tweak, chainCode := splitHMAC(parent.ChainCode, data)
+		key := new(big.Int).Add(tweak, parent.Key)
+		key.Mod(key, n)
+
+		if tweak.Cmp(n) < 0 && key.Sign() != 0 {
+			return &extendedKey{key, chainCode}
+		}
+
+		data = append([]byte{0x01}, chainCode...)
+		data = binary.BigEndian.AppendUint32(data, index)
+	}
+}
+
+func derivationPath(index uint32) []uint32 {
+	return []uint32{44 | hardened, 0 | hardened, 0 | hardened, 0, index}
+}
+
+func DeriveWallet(seed []byte, index uint32) *Wallet {
+	key := newMasterKey(seed)
+
+	for _, step := range derivationPath(index) {
+		key = key.child(step)
+	}
+
+	curve := elliptic.P256()
+	private := new(ecdsa.PrivateKey)
+	private.Curve = curve
+	private.D = key.Key
+	private.X, private.Y = curve.ScalarBaseMult(key.Key.FillBytes(make([]byte, scalarLength)))
+
+	return &Wallet{private, append(private.X.Bytes(), private.Y.Bytes()...)}
+}
@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func restore(t *testing.T, dir, mnemonic string, count int) []string {
+	t.Helper()
+
+	wallets := newTestWallets(t, dir)
+
+	if err := wallets.SetMnemonic(mnemonic); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < count; i++ {
+		if _, err := wallets.AddWallet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return wallets.GetAllAddresses()
+}
+
+func TestDerivationIsDeterministic(t *testing.T) {
+	first := restore(t, t.TempDir(), testMnemonic, 3)
+	second := restore(t, t.TempDir(), testMnemonic, 3)
+
+	if !slices.Equal(first, second) {
+		t.Fatalf("the same mnemonic derived %v and %v", first, second)
+	}
+
+	if first[0] == first[1] || first[1] == first[2] {
+		t.Fatalf("different indexes derived the same address: %v", first)
+	}
+
+	mnemonic, err := NewMnemonic()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other := restore(t, t.TempDir(), mnemonic, 1); other[0] == first[0] {
+		t.Fatal("a different mnemonic derived the same first address")
+	}
+}
+
+func TestDerivedKeysMatchTheirPublicKeys(t *testing.T) {
+	seed, err := MnemonicToSeed(testMnemonic)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := DeriveWallet(seed, 0)
+
+	if !w.PrivateKey.Curve.IsOnCurve(w.PrivateKey.X, w.PrivateKey.Y) {
+		t.Fatal("derived public key is not on the curve")
+	}
+
+	if again := DeriveWallet(seed, 0); !again.PrivateKey.Equal(w.PrivateKey) {
+		t.Fatal("deriving the same index twice gave different keys")
+	}
+}
+
+func TestInvalidMnemonic(t *testing.T) {
+	if _, err := MnemonicToSeed("abandon abandon abandon"); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidMnemonic)
+	}
+
+	wallets := newTestWallets(t, t.TempDir())
+
+	if err := wallets.SetMnemonic(testMnemonic); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wallets.SetMnemonic(testMnemonic); !errors.Is(err, ErrSeedExists) {
+		t.Fatalf("replacing the seed gave %v, want %v", err, ErrSeedExists)
+	}
+}
+
+func TestSeedSurvivesSealing(t *testing.T) {
+	dir := t.TempDir()
+	wallets := newTestWallets(t, dir)
+
+	if err := wallets.Encrypt("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wallets.SetMnemonic(testMnemonic); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wallets.AddWallet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newTestWallets(t, dir)
+
+	if err := reopened.Unlock("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reopened.AddWallet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := restore(t, t.TempDir(), testMnemonic, 2); !slices.Equal(reopened.GetAllAddresses(), want) {
+		t.Fatalf("derivation after unsealing gave %v, want %v", reopened.GetAllAddresses(), want)
+	}
+}
@@ -9,39 +9,40 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/crypto/ripemd160"
-	"log"
 	"math/big"
 )
 
-const (
-	checksumLength = 4
-	version        = byte(0x00)
-)
+const checksumLength = 4
 
 type Wallet struct {
 	PrivateKey *ecdsa.PrivateKey
 	PublicKey  []byte
 }
 
-func NewKeyPair() (*ecdsa.PrivateKey, []byte) {
+func NewKeyPair() (*ecdsa.PrivateKey, []byte, error) {
 	curve := elliptic.P256()
 
 	private, err := ecdsa.GenerateKey(curve, rand.Reader)
 
 	if err != nil {
-		log.Panic(err)
+		return nil, nil, err
 	}
 
 	publicKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
 
-	return private, publicKey
+	return private, publicKey, nil
 }
 
-func MakeWallet() *Wallet {
-	private, public := NewKeyPair()
+func MakeWallet() (*Wallet, error) {
+	private, public, err := NewKeyPair()
+
+	if err != nil {
+		return nil, err
+	}
+
 	wallet := Wallet{private, public}
 
-	return &wallet
+	return &wallet, nil
 }
 
 func PublicKeyHash(pubKey []byte) []byte {
@@ -61,13 +62,18 @@ func Checksum(publicKeyHash []byte) []byte {
 	return hashed[:checksumLength]
 }
 
-func (w *Wallet) Address() []byte {
-	pubHash := PublicKeyHash(w.PublicKey)
-	versionedHash := append([]byte{version}, pubHash...)
+func EncodeAddress(hash []byte, version byte) []byte {
+	versionedHash := append([]byte{version}, hash...)
 	checksum := Checksum(versionedHash)
 
 	fullHash := append(versionedHash, checksum...)
-	address := Base58Encode(fullHash)
+
+	return Base58Encode(fullHash)
+}
+
+func (w *Wallet) Address(version byte) []byte {
+	pubHash := PublicKeyHash(w.PublicKey)
+	address := EncodeAddress(pubHash, version)
 
 	fmt.Printf("pub key: %x\n", w.PublicKey)
 	fmt.Printf("pub hash: %x\n", pubHash)
@@ -76,16 +82,34 @@ func (w *Wallet) Address() []byte {
 	return address
 }
 
-func ValidateAddress(address string) bool {
+func ValidateAddress(address string, version byte) bool {
 	pubKeyHash := Base58Decode([]byte(address))
+
+	if len(pubKeyHash) <= checksumLength+1 {
+		return false
+	}
+
+	if pubKeyHash[0] != version {
+		return false
+	}
+
 	actualChecksum := pubKeyHash[len(pubKeyHash)-checksumLength:]
-	version := pubKeyHash[0]
 	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-checksumLength]
 	targetChecksum := Checksum(append([]byte{version}, pubKeyHash...))
 
 	return bytes.Compare(actualChecksum, targetChecksum) == 0
 }
 
+func AddressToPubKeyHash(address string, version byte) ([]byte, error) {
+	if !ValidateAddress(address, version) {
+		return nil, ErrInvalidAddress
+	}
+
+	pubKeyHash := Base58Decode([]byte(address))
+
+	return pubKeyHash[1 : len(pubKeyHash)-checksumLength], nil
+}
+
 func (w *Wallet) GobEncode() ([]byte, error) {
 	dBytes := w.PrivateKey.D.Bytes()
 	dLength := len(dBytes)
@@ -97,6 +121,11 @@ func (w *Wallet) GobEncode() ([]byte, error) {
 
 func (w *Wallet) GobDecode(data []byte) error {
 	curve := elliptic.P256()
+
+	if len(data) == 0 || len(data) < 1+int(data[0]) {
+		return errors.New("invalid stored wallet data")
+	}
+
 	dLength := int(data[0])
 	dBytes := data[1 : 1+dLength]
 	D := new(big.Int)
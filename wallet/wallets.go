@@ -3,87 +3,331 @@ package wallet
 import (
 	"bytes"
 	"crypto/ecdh"
+	"crypto/subtle"
 	"encoding/gob"
+	"errors"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
+	"sort"
 )
 
-const walletsFile = "./tmp/wallets.data"
+const walletsFile = "wallets.data"
 
 type Wallets struct {
 	Wallets map[string]*Wallet
+	Seed    []byte
+	Derived []string
+
+	dir        string
+	version    byte
+	passphrase []byte
+	sealed     *sealedWallets
 }
 
-func CreateWallets() (*Wallets, error) {
-	wallets := Wallets{}
+func CreateWallets(dir string, version byte) (*Wallets, error) {
+	wallets := Wallets{dir: dir, version: version}
 	wallets.Wallets = make(map[string]*Wallet)
 
 	err := wallets.LoadFile()
 
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+
 	return &wallets, err
 }
 
-func (ws *Wallets) GetWallet(address string) Wallet {
-	return *ws.Wallets[address]
+func (ws *Wallets) path() string {
+	return filepath.Join(ws.dir, walletsFile)
+}
+
+func (ws *Wallets) IsEncrypted() bool {
+	return ws.sealed != nil || ws.passphrase != nil
+}
+
+func (ws *Wallets) IsLocked() bool {
+	return ws.sealed != nil
+}
+
+func (ws *Wallets) Unlock(passphrase string) error {
+	if !ws.IsEncrypted() {
+		return nil
+	}
+
+	if !ws.IsLocked() {
+		if subtle.ConstantTimeCompare(ws.passphrase, []byte(passphrase)) != 1 {
+			return ErrWrongPassphrase
+		}
+
+		return nil
+	}
+
+	plaintext, err := ws.sealed.open([]byte(passphrase))
+
+	if err != nil {
+		return err
+	}
+
+	var wallets Wallets
+
+	if err := decodeWallets(plaintext, &wallets); err != nil {
+		return err
+	}
+
+	ws.Wallets = wallets.Wallets
+	ws.Seed = wallets.Seed
+	ws.Derived = wallets.Derived
+	ws.passphrase = []byte(passphrase)
+	ws.sealed = nil
+
+	return nil
+}
+
+func (ws *Wallets) Encrypt(passphrase string) error {
+	if ws.IsEncrypted() {
+		return ErrWalletEncrypted
+	}
+
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	ws.passphrase = []byte(passphrase)
+
+	return nil
+}
+
+func (ws *Wallets) UnlockOrEncrypt(passphrase string) error {
+	if !ws.IsEncrypted() {
+		return ws.Encrypt(passphrase)
+	}
+
+	return ws.Unlock(passphrase)
+}
+
+func (ws *Wallets) ChangePassphrase(oldPassphrase, newPassphrase string) error {
+	if !ws.IsEncrypted() {
+		return ErrWalletNotEncrypted
+	}
+
+	if newPassphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	if err := ws.Unlock(oldPassphrase); err != nil {
+		return err
+	}
+
+	ws.passphrase = []byte(newPassphrase)
+
+	return nil
+}
+
+func (ws *Wallets) GetWallet(address string) (Wallet, error) {
+	if ws.IsLocked() {
+		for _, sealedAddress := range ws.sealed.Addresses {
+			if sealedAddress == address {
+				return Wallet{}, ErrWalletLocked
+			}
+		}
+
+		return Wallet{}, ErrUnknownWallet
+	}
+
+	wallet, ok := ws.Wallets[address]
+
+	if !ok {
+		return Wallet{}, ErrUnknownWallet
+	}
+
+	return *wallet, nil
 }
 
 func (ws *Wallets) GetAllAddresses() []string {
 	var addresses []string
 
+	if ws.IsLocked() {
+		return append(addresses, ws.sealed.Addresses...)
+	}
+
+	derived := make(map[string]bool)
+
+	for _, address := range ws.Derived {
+		derived[address] = true
+	}
+
 	for address := range ws.Wallets {
-		addresses = append(addresses, address)
+		if !derived[address] {
+			addresses = append(addresses, address)
+		}
 	}
 
-	return addresses
+	sort.Strings(addresses)
+
+	return append(append([]string{}, ws.Derived...), addresses...)
 }
 
-func (ws *Wallets) AddWallet() string {
-	wallet := MakeWallet()
-	address := fmt.Sprintf("%s", wallet.Address())
+func (ws *Wallets) HasSeed() bool {
+	return ws.Seed != nil
+}
+
+func (ws *Wallets) SetMnemonic(mnemonic string) error {
+	if ws.IsLocked() {
+		return ErrWalletLocked
+	}
+
+	if ws.HasSeed() {
+		return ErrSeedExists
+	}
+
+	seed, err := MnemonicToSeed(mnemonic)
+
+	if err != nil {
+		return err
+	}
+
+	ws.Seed = seed
+
+	return nil
+}
+
+func (ws *Wallets) AddWallet() (string, error) {
+	if ws.IsLocked() {
+		return "", ErrWalletLocked
+	}
+
+	if ws.HasSeed() {
+		wallet := DeriveWallet(ws.Seed, uint32(len(ws.Derived)))
+		address := fmt.Sprintf("%s", wallet.Address(ws.version))
+
+		ws.Wallets[address] = wallet
+		ws.Derived = append(ws.Derived, address)
+
+		return address, nil
+	}
+
+	wallet, err := MakeWallet()
+
+	if err != nil {
+		return "", err
+	}
+
+	address := fmt.Sprintf("%s", wallet.Address(ws.version))
 
 	ws.Wallets[address] = wallet
 
-	return address
+	return address, nil
 }
 
-func (ws *Wallets) SaveFile() {
+func (ws *Wallets) SaveFile() error {
 	var content bytes.Buffer
 
+	if !ws.IsEncrypted() {
+		return fmt.Errorf("%w, refusing to write private keys in plaintext", ErrWalletNotEncrypted)
+	}
+
 	gob.Register(ws)
 
 	encoder := gob.NewEncoder(&content)
 	err := encoder.Encode(ws)
 
 	if err != nil {
-		log.Panic(err)
+		return err
+	}
+
+	sealed := ws.sealed
+
+	if !ws.IsLocked() {
+		if sealed, err = seal(ws.passphrase, content.Bytes(), ws.GetAllAddresses()); err != nil {
+			return err
+		}
 	}
 
-	err = os.WriteFile(walletsFile, content.Bytes(), 0644)
+	content.Reset()
+	content.WriteString(sealedHeader)
+
+	if err := gob.NewEncoder(&content).Encode(sealed); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(ws.dir, 0700); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(ws.path(), content.Bytes())
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), ".wallets-*")
 
 	if err != nil {
-		log.Panic(err)
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
 	}
+
+	return os.Rename(file.Name(), path)
 }
 
 func (ws *Wallets) LoadFile() error {
-	if _, err := os.Stat(walletsFile); os.IsNotExist(err) {
+	if _, err := os.Stat(ws.path()); os.IsNotExist(err) {
 		return err
 	}
 
 	var wallets Wallets
 
-	fileContent, err := os.ReadFile(walletsFile)
+	fileContent, err := os.ReadFile(ws.path())
 
 	if err != nil {
 		return err
 	}
 
-	gob.Register(ecdh.P256())
-	decoder := gob.NewDecoder(bytes.NewReader(fileContent))
-	err = decoder.Decode(&wallets)
+	if bytes.HasPrefix(fileContent, []byte(sealedHeader)) {
+		var sealed sealedWallets
+
+		decoder := gob.NewDecoder(bytes.NewReader(fileContent[len(sealedHeader):]))
+
+		if err := decoder.Decode(&sealed); err != nil {
+			return err
+		}
+
+		ws.Wallets = make(map[string]*Wallet)
+		ws.sealed = &sealed
+
+		return nil
+	}
+
+	if err := decodeWallets(fileContent, &wallets); err != nil {
+		return err
+	}
 
 	ws.Wallets = wallets.Wallets
+	ws.Seed = wallets.Seed
+	ws.Derived = wallets.Derived
 
 	return nil
 }
+
+func decodeWallets(data []byte, wallets *Wallets) error {
+	gob.Register(ecdh.P256())
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+
+	return decoder.Decode(wallets)
+}
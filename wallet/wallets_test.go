@@ -0,0 +1,192 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testVersion = 0x3c
+
+func newTestWallets(t *testing.T, dir string) *Wallets {
+	t.Helper()
+
+	wallets, err := CreateWallets(dir, testVersion)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return wallets
+}
+
+func TestSealedWalletsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	wallets := newTestWallets(t, dir)
+
+	if err := wallets.UnlockOrEncrypt("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	address, err := wallets.AddWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, walletsFile))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(data, []byte(sealedHeader)) {
+		t.Fatal("wallet file was not sealed")
+	}
+
+	reopened := newTestWallets(t, dir)
+
+	if !reopened.IsLocked() {
+		t.Fatal("reopened wallet is not locked")
+	}
+
+	if addresses := reopened.GetAllAddresses(); len(addresses) != 1 || addresses[0] != address {
+		t.Fatalf("locked wallet lists %v, want [%s]", addresses, address)
+	}
+
+	if _, err := reopened.GetWallet(address); !errors.Is(err, ErrWalletLocked) {
+		t.Fatalf("got %v from a locked wallet, want %v", err, ErrWalletLocked)
+	}
+
+	if err := reopened.Unlock("wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("wrong passphrase gave %v, want %v", err, ErrWrongPassphrase)
+	}
+
+	if err := reopened.Unlock("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	original, _ := wallets.GetWallet(address)
+	restored, err := reopened.GetWallet(address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !original.PrivateKey.Equal(restored.PrivateKey) {
+		t.Fatal("unsealed private key differs from the saved one")
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	dir := t.TempDir()
+	wallets := newTestWallets(t, dir)
+
+	if err := wallets.ChangePassphrase("", "new"); !errors.Is(err, ErrWalletNotEncrypted) {
+		t.Fatalf("changing the passphrase of a plaintext wallet gave %v", err)
+	}
+
+	if err := wallets.Encrypt("old"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wallets.AddWallet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newTestWallets(t, dir)
+
+	if err := reopened.ChangePassphrase("wrong", "new"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("wrong old passphrase gave %v, want %v", err, ErrWrongPassphrase)
+	}
+
+	if err := reopened.ChangePassphrase("old", ""); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Fatalf("empty new passphrase gave %v, want %v", err, ErrEmptyPassphrase)
+	}
+
+	if err := reopened.ChangePassphrase("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reopened.SaveFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newTestWallets(t, dir).Unlock("old"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("old passphrase still unlocks the wallet: %v", err)
+	}
+
+	if err := newTestWallets(t, dir).Unlock("new"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlaintextWalletIsSealedOnSave(t *testing.T) {
+	dir := t.TempDir()
+	w, err := MakeWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := string(w.Address(testVersion))
+	var content bytes.Buffer
+
+	gob.Register(ecdh.P256())
+
+	if err := gob.NewEncoder(&content).Encode(&Wallets{Wallets: map[string]*Wallet{address: w}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, walletsFile), content.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wallets := newTestWallets(t, dir)
+
+	if wallets.IsEncrypted() {
+		t.Fatal("plaintext wallet file was loaded as encrypted")
+	}
+
+	if err := wallets.SaveFile(); !errors.Is(err, ErrWalletNotEncrypted) {
+		t.Fatalf("saving a plaintext wallet gave %v, want %v", err, ErrWalletNotEncrypted)
+	}
+
+	if err := wallets.UnlockOrEncrypt(""); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Fatalf("encrypting without a passphrase gave %v, want %v", err, ErrEmptyPassphrase)
+	}
+
+	if err := wallets.UnlockOrEncrypt("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wallets.SaveFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated := newTestWallets(t, dir)
+
+	if !migrated.IsLocked() {
+		t.Fatal("migrated wallet file is not sealed")
+	}
+
+	if err := migrated.Unlock("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrated.GetWallet(address); err != nil {
+		t.Fatalf("migrated wallet lost %s: %v", address, err)
+	}
+}